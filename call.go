@@ -0,0 +1,144 @@
+package huawei
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Node is an element of a generic XML tree, as returned by Call.
+type Node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []*Node    `xml:",any"`
+}
+
+// RawResponse is the answer of the device to a Call, decoded into a generic
+// XML tree rooted at the <response> element.
+type RawResponse struct {
+	*Node
+	// Body is the undecoded response body.
+	Body []byte
+}
+
+// Call sends a request to any API endpoint of the device, for endpoints the
+// library has no dedicated method for. It uses the same session, token,
+// re-login and retry handling as every other method, except that requests
+// other than GET are assumed to change the device state and so are never
+// repeated after a failure the device may already have acted on.
+//
+// body may be nil for an empty request, a string or []byte holding a raw XML
+// document, or any value encoding/xml can marshal; such values should name
+// their root element "request".
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - method: The HTTP method to use (e.g., "GET", "POST").
+//   - path: The API path, e.g. "/api/device/signal".
+//   - body: The request body, or nil.
+//
+// Returns:
+//   - *RawResponse: The response decoded into a generic XML tree.
+//   - error: An error if the request fails, the device answers with an error
+//     or the response is not XML.
+func (h *Huawei) Call(ctx context.Context, method, path string, body any) (*RawResponse, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var payload string
+	switch b := body.(type) {
+	case string:
+		payload = b
+	case []byte:
+		payload = string(b)
+	default:
+		var err error
+		if payload, err = encodeRequest(body); err != nil {
+			return nil, err
+		}
+	}
+
+	respBody, err := h.send(ctx, method, path, payload, method == http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	root, err := decodeResponse[Node](path, respBody)
+	if err != nil {
+		return nil, err
+	}
+	return &RawResponse{Node: root, Body: respBody}, nil
+}
+
+// Child returns the first child element with the given name, or nil.
+// name may be a slash separated path such as "Messages/Message".
+func (n *Node) Child(name string) *Node {
+	if n == nil {
+		return nil
+	}
+	first, rest, nested := strings.Cut(name, "/")
+	for _, c := range n.Children {
+		if c.XMLName.Local == first {
+			if nested {
+				return c.Child(rest)
+			}
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns all direct child elements with the given name.
+func (n *Node) ChildrenNamed(name string) []*Node {
+	if n == nil {
+		return nil
+	}
+	var nodes []*Node
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// Text returns the trimmed text of the element.
+func (n *Node) Text() string {
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(n.Content)
+}
+
+// Get returns the trimmed text of the child element with the given name,
+// or an empty string if there is no such child. See Child for name.
+func (n *Node) Get(name string) string {
+	return n.Child(name).Text()
+}
+
+// Int returns the text of the child element with the given name parsed as
+// an integer. See Child for name.
+func (n *Node) Int(name string) (int, error) {
+	c := n.Child(name)
+	if c == nil {
+		return 0, fmt.Errorf("element %q not found", name)
+	}
+	return strconv.Atoi(c.Text())
+}
+
+// Map returns the text of every direct child element keyed by name, which
+// suits the flat responses most endpoints return.
+func (n *Node) Map() map[string]string {
+	m := make(map[string]string)
+	if n == nil {
+		return m
+	}
+	for _, c := range n.Children {
+		m[c.XMLName.Local] = c.Text()
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package huawei
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCallDoesNotRepeatPosts(t *testing.T) {
+	d := newFakeDevice(t)
+	// Drop the connection after the device may have acted.
+	hangUp := func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+	d.handle("/api/device/control", hangUp)
+	d.handle("/api/device/information", hangUp)
+
+	h, err := NewHuawei(d.URL(), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := h.Call(ctx, "POST", "/api/device/control", `<request><Control>1</Control></request>`); err == nil {
+		t.Error("Call succeeded on a dropped connection")
+	}
+	if n := d.count("/api/device/control"); n != 1 {
+		t.Errorf("POST sent %d times, want 1", n)
+	}
+
+	if _, err := h.Call(ctx, "GET", "/api/device/information", nil); err == nil {
+		t.Error("Call succeeded on a dropped connection")
+	}
+	if n := d.count("/api/device/information"); n != 3 {
+		t.Errorf("GET sent %d times, want 3", n)
+	}
+}
@@ -0,0 +1,84 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+)
+
+// SaveDraft saves msg as a new draft in the local draft box of the device.
+// It sends a POST request to the /api/sms/save-sms endpoint.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - msg: The text of the draft.
+//   - phones: The recipients of the draft, possibly none.
+//
+// Returns:
+//   - error: An error if the request fails or the device rejects it.
+func (h *Huawei) SaveDraft(ctx context.Context, msg string, phones ...string) error {
+	_, err := do[Response](ctx, h, "POST", "/api/sms/save-sms", h.newSMSRequest(-1, msg, phones))
+	return err
+}
+
+// UpdateDraft replaces the text and recipients of the draft at index.
+// It sends a POST request to the /api/sms/save-sms endpoint.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - index: The index of the draft, as listed by ListDrafts.
+//   - msg: The new text of the draft.
+//   - phones: The new recipients of the draft.
+//
+// Returns:
+//   - error: An error if the request fails or the device rejects it.
+func (h *Huawei) UpdateDraft(ctx context.Context, index int, msg string, phones ...string) error {
+	_, err := do[Response](ctx, h, "POST", "/api/sms/save-sms", h.newSMSRequest(index, msg, phones))
+	return err
+}
+
+// ListDrafts retrieves every draft in the local draft box, newest first.
+//
+// Returns:
+//   - []SMS: The drafts. Phone holds their recipients separated by ';', see
+//     Recipients.
+//   - error: An error if listing the drafts fails.
+func (h *Huawei) ListDrafts(ctx context.Context) ([]SMS, error) {
+	var drafts []SMS
+	for msg, err := range h.AllSMS(ctx, ListOptions{Box: Draft}) {
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, msg)
+	}
+	return drafts, nil
+}
+
+// SendDraft sends the draft at index to its recipients. The device moves
+// the draft to the outbox once it is sent; use Wait on the result to learn
+// whether it reached every recipient.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the requests.
+//   - index: The index of the draft, as listed by ListDrafts.
+//
+// Returns:
+//   - *SendResult: The pending send, to be awaited with Wait.
+//   - error: An error if the draft does not exist, has no recipients or
+//     the device does not accept it.
+func (h *Huawei) SendDraft(ctx context.Context, index int) (*SendResult, error) {
+	drafts, err := h.ListDrafts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, draft := range drafts {
+		if draft.Index != index {
+			continue
+		}
+		phones := draft.Recipients()
+		if len(phones) == 0 {
+			return nil, fmt.Errorf("draft %d has no recipients", index)
+		}
+		return h.sendSMS(ctx, h.newSMSRequest(index, draft.Content, phones))
+	}
+	return nil, fmt.Errorf("draft %d not found", index)
+}
@@ -1,12 +1,287 @@
 package huawei
 
-var ERROR_SYSTEM_NO_SUPPORT = 100002
-var ERROR_SYSTEM_NO_RIGHTS = "100003"
-var ERROR_SYSTEM_BUSY = 100004
-var ERROR_LOGIN_USERNAME_WRONG = 108001
-var ERROR_LOGIN_PASSWORD_WRONG = 108002
-var ERROR_LOGIN_ALREADY_LOGIN = 108003
-var ERROR_LOGIN_USERNAME_PWD_WRONG = 108006
-var ERROR_LOGIN_USERNAME_PWD_ORERRUN = 108007
-var ERROR_VOICE_BUSY = 120001
-var ERROR_WRONG_TOKEN = 125001
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error codes returned by HiLink firmware in <error><code> responses.
+// Names follow the ones used by the device web interface.
+const (
+	// System
+	ERROR_UNKNOWN                = 100001
+	ERROR_SYSTEM_NO_SUPPORT      = 100002
+	ERROR_SYSTEM_NO_RIGHTS       = 100003
+	ERROR_SYSTEM_BUSY            = 100004
+	ERROR_FORMAT_ERROR           = 100005
+	ERROR_PARAMETER_ERROR        = 100006
+	ERROR_SAVE_CONFIG_FILE_ERROR = 100007
+	ERROR_GET_CONFIG_FILE_ERROR  = 100008
+
+	// SIM and PIN
+	ERROR_NO_SIM_CARD_OR_INVALID_SIM_CARD = 101001
+	ERROR_CHECK_SIM_CARD_PIN_LOCK         = 101002
+	ERROR_CHECK_SIM_CARD_PUK_LOCK         = 101003
+	ERROR_CHECK_SIM_CARD_CAN_UNUSEABLE    = 101004
+	ERROR_ENABLE_PIN_FAILED               = 101005
+	ERROR_DISABLE_PIN_FAILED              = 101006
+	ERROR_UNLOCK_PIN_FAILED               = 101007
+	ERROR_DISABLE_AUTO_PIN_FAILED         = 101008
+	ERROR_ENABLE_AUTO_PIN_FAILED          = 101009
+
+	// Network status
+	ERROR_GET_NET_TYPE_FAILED       = 102001
+	ERROR_GET_SERVICE_STATUS_FAILED = 102002
+	ERROR_GET_ROAM_STATUS_FAILED    = 102003
+	ERROR_GET_CONNECT_STATUS_FAILED = 102004
+
+	// Device
+	ERROR_DEVICE_AT_EXECUTE_FAILED    = 103001
+	ERROR_DEVICE_PIN_VALIDATE_FAILED  = 103002
+	ERROR_DEVICE_PIN_MODIFFY_FAILED   = 103003
+	ERROR_DEVICE_PUK_MODIFFY_FAILED   = 103004
+	ERROR_DEVICE_SIM_CARD_BUSY        = 103008
+	ERROR_DEVICE_SIM_LOCK_INPUT_ERROR = 103009
+	ERROR_DEVICE_PUK_DEAD_LOCK        = 103011
+
+	// Dial-up
+	ERROR_DIALUP_GET_CONNECT_FILE_ERROR       = 107720
+	ERROR_DIALUP_SAVE_CONNECT_FILE_ERROR      = 107721
+	ERROR_DIALUP_DIALUP_MANAGMENT_PARSE_ERROR = 107722
+	ERROR_DIALUP_ADD_PRORILE_ERROR            = 107724
+	ERROR_DIALUP_MODIFY_PRORILE_ERROR         = 107725
+	ERROR_DIALUP_SET_DEFAULT_PRORILE_ERROR    = 107726
+	ERROR_DIALUP_GET_PRORILE_LIST_ERROR       = 107727
+	ERROR_DIALUP_GET_AUTO_APN_MATCH_ERROR     = 107728
+	ERROR_DIALUP_SET_AUTO_APN_MATCH_ERROR     = 107729
+
+	// Login
+	ERROR_LOGIN_USERNAME_WRONG         = 108001
+	ERROR_LOGIN_PASSWORD_WRONG         = 108002
+	ERROR_LOGIN_ALREADY_LOGIN          = 108003
+	ERROR_LOGIN_MODIFY_PASSWORD_FAILED = 108004
+	ERROR_LOGIN_TOO_MANY_USERS_LOGINED = 108005
+	ERROR_LOGIN_USERNAME_PWD_WRONG     = 108006
+	ERROR_LOGIN_USERNAME_PWD_ORERRUN   = 108007
+	ERROR_LOGIN_IN_DEFFERENT_DEVICES   = 108009
+	ERROR_LOGIN_FREQUENTLY             = 108010
+
+	// Network registration and mode
+	ERROR_SET_NET_MODE_AND_BAND_WHEN_DAILUP_FAILED = 112001
+	ERROR_SET_NET_SEARCH_MODE_WHEN_DAILUP_FAILED   = 112002
+	ERROR_SET_NET_MODE_AND_BAND_FAILED             = 112003
+	ERROR_SET_NET_SEARCH_MODE_FAILED               = 112004
+	ERROR_NET_REGISTER_NET_FAILED                  = 112005
+	ERROR_NET_NET_CONNECTED_ORDER_NOT_MATCH        = 112006
+	ERROR_NET_CURRENT_NET_MODE_NOT_SUPPORT         = 112007
+	ERROR_NET_SIM_CARD_NOT_READY_STATUS            = 112008
+	ERROR_NET_MEMORY_ALLOC_FAILED                  = 112009
+
+	// SMS
+	ERROR_SMS_NULL_ARGUMENT_OR_ILLEGAL_ARGUMENT = 113017
+	ERROR_SMS_STORAGE_FULL                      = 113018
+	ERROR_SMS_QUERY_SMS_INDEX_LIST_ERROR        = 113020
+	ERROR_SMS_SET_SMS_CENTER_NUMBER_FAILED      = 113031
+	ERROR_SMS_DELETE_SMS_FAILED                 = 113036
+	ERROR_SMS_SAVE_CONFIG_FILE_FAILED           = 113047
+	ERROR_SMS_LOCAL_SPACE_NOT_ENOUGH            = 113053
+	ERROR_SMS_TELEPHONE_NUMBER_TOO_LONG         = 113054
+
+	// USSD
+	ERROR_USSD_ERROR                 = 111001
+	ERROR_USSD_FUCNTION_RETURN_ERROR = 111012
+	ERROR_USSD_IN_USSD_SESSION       = 111013
+	ERROR_USSD_TOO_LONG_CONTENT      = 111014
+	ERROR_USSD_EMPTY_COMMAND         = 111016
+	ERROR_USSD_CODING_ERROR          = 111017
+	ERROR_USSD_AT_SEND_FAILED        = 111018
+	ERROR_USSD_NET_NO_RETURN         = 111019
+	ERROR_USSD_NET_OVERTIME          = 111020
+	ERROR_USSD_NET_NOT_SUPPORT_USSD  = 111022
+
+	// Voice
+	ERROR_VOICE_BUSY = 120001
+
+	// Session and token
+	ERROR_WRONG_TOKEN         = 125001
+	ERROR_WRONG_SESSION       = 125002
+	ERROR_WRONG_SESSION_TOKEN = 125003
+)
+
+// errorMessages maps known error codes to human readable messages.
+var errorMessages = map[int]string{
+	ERROR_UNKNOWN:                "unknown error",
+	ERROR_SYSTEM_NO_SUPPORT:      "not supported by the device",
+	ERROR_SYSTEM_NO_RIGHTS:       "not logged in or no rights",
+	ERROR_SYSTEM_BUSY:            "device busy",
+	ERROR_FORMAT_ERROR:           "request format error",
+	ERROR_PARAMETER_ERROR:        "invalid parameter",
+	ERROR_SAVE_CONFIG_FILE_ERROR: "saving configuration failed",
+	ERROR_GET_CONFIG_FILE_ERROR:  "reading configuration failed",
+
+	ERROR_NO_SIM_CARD_OR_INVALID_SIM_CARD: "no SIM card or invalid SIM card",
+	ERROR_CHECK_SIM_CARD_PIN_LOCK:         "SIM card locked, PIN required",
+	ERROR_CHECK_SIM_CARD_PUK_LOCK:         "SIM card locked, PUK required",
+	ERROR_CHECK_SIM_CARD_CAN_UNUSEABLE:    "SIM card unusable",
+	ERROR_ENABLE_PIN_FAILED:               "enabling PIN failed",
+	ERROR_DISABLE_PIN_FAILED:              "disabling PIN failed",
+	ERROR_UNLOCK_PIN_FAILED:               "unlocking PIN failed",
+	ERROR_DISABLE_AUTO_PIN_FAILED:         "disabling auto PIN failed",
+	ERROR_ENABLE_AUTO_PIN_FAILED:          "enabling auto PIN failed",
+
+	ERROR_GET_NET_TYPE_FAILED:       "getting network type failed",
+	ERROR_GET_SERVICE_STATUS_FAILED: "getting service status failed",
+	ERROR_GET_ROAM_STATUS_FAILED:    "getting roaming status failed",
+	ERROR_GET_CONNECT_STATUS_FAILED: "getting connection status failed",
+
+	ERROR_DEVICE_AT_EXECUTE_FAILED:    "AT command failed",
+	ERROR_DEVICE_PIN_VALIDATE_FAILED:  "wrong PIN",
+	ERROR_DEVICE_PIN_MODIFFY_FAILED:   "changing PIN failed",
+	ERROR_DEVICE_PUK_MODIFFY_FAILED:   "wrong PUK",
+	ERROR_DEVICE_SIM_CARD_BUSY:        "SIM card busy",
+	ERROR_DEVICE_SIM_LOCK_INPUT_ERROR: "wrong SIM lock code",
+	ERROR_DEVICE_PUK_DEAD_LOCK:        "PUK attempts exhausted, SIM card permanently locked",
+
+	ERROR_DIALUP_GET_CONNECT_FILE_ERROR:       "reading dial-up configuration failed",
+	ERROR_DIALUP_SAVE_CONNECT_FILE_ERROR:      "saving dial-up configuration failed",
+	ERROR_DIALUP_DIALUP_MANAGMENT_PARSE_ERROR: "invalid dial-up settings",
+	ERROR_DIALUP_ADD_PRORILE_ERROR:            "adding profile failed",
+	ERROR_DIALUP_MODIFY_PRORILE_ERROR:         "modifying profile failed",
+	ERROR_DIALUP_SET_DEFAULT_PRORILE_ERROR:    "setting default profile failed",
+	ERROR_DIALUP_GET_PRORILE_LIST_ERROR:       "getting profile list failed",
+	ERROR_DIALUP_GET_AUTO_APN_MATCH_ERROR:     "getting automatic APN matching failed",
+	ERROR_DIALUP_SET_AUTO_APN_MATCH_ERROR:     "setting automatic APN matching failed",
+
+	ERROR_LOGIN_USERNAME_WRONG:         "wrong username",
+	ERROR_LOGIN_PASSWORD_WRONG:         "wrong password",
+	ERROR_LOGIN_ALREADY_LOGIN:          "already logged in",
+	ERROR_LOGIN_MODIFY_PASSWORD_FAILED: "changing password failed",
+	ERROR_LOGIN_TOO_MANY_USERS_LOGINED: "too many users logged in",
+	ERROR_LOGIN_USERNAME_PWD_WRONG:     "wrong username or password",
+	ERROR_LOGIN_USERNAME_PWD_ORERRUN:   "too many failed login attempts, login locked",
+	ERROR_LOGIN_IN_DEFFERENT_DEVICES:   "logged in from another device",
+	ERROR_LOGIN_FREQUENTLY:             "login attempted too frequently",
+
+	ERROR_SET_NET_MODE_AND_BAND_WHEN_DAILUP_FAILED: "cannot set network mode while connected",
+	ERROR_SET_NET_SEARCH_MODE_WHEN_DAILUP_FAILED:   "cannot set network search mode while connected",
+	ERROR_SET_NET_MODE_AND_BAND_FAILED:             "setting network mode failed",
+	ERROR_SET_NET_SEARCH_MODE_FAILED:               "setting network search mode failed",
+	ERROR_NET_REGISTER_NET_FAILED:                  "network registration failed",
+	ERROR_NET_NET_CONNECTED_ORDER_NOT_MATCH:        "network connection order mismatch",
+	ERROR_NET_CURRENT_NET_MODE_NOT_SUPPORT:         "network mode not supported",
+	ERROR_NET_SIM_CARD_NOT_READY_STATUS:            "SIM card not ready",
+	ERROR_NET_MEMORY_ALLOC_FAILED:                  "device out of memory",
+
+	ERROR_SMS_NULL_ARGUMENT_OR_ILLEGAL_ARGUMENT: "invalid SMS argument",
+	ERROR_SMS_STORAGE_FULL:                      "SMS storage full",
+	ERROR_SMS_QUERY_SMS_INDEX_LIST_ERROR:        "querying SMS list failed",
+	ERROR_SMS_SET_SMS_CENTER_NUMBER_FAILED:      "setting SMS center number failed",
+	ERROR_SMS_DELETE_SMS_FAILED:                 "deleting SMS failed",
+	ERROR_SMS_SAVE_CONFIG_FILE_FAILED:           "saving SMS configuration failed",
+	ERROR_SMS_LOCAL_SPACE_NOT_ENOUGH:            "not enough local SMS storage",
+	ERROR_SMS_TELEPHONE_NUMBER_TOO_LONG:         "phone number too long",
+
+	ERROR_USSD_ERROR:                 "USSD error",
+	ERROR_USSD_FUCNTION_RETURN_ERROR: "USSD function failed",
+	ERROR_USSD_IN_USSD_SESSION:       "USSD session in progress",
+	ERROR_USSD_TOO_LONG_CONTENT:      "USSD content too long",
+	ERROR_USSD_EMPTY_COMMAND:         "empty USSD command",
+	ERROR_USSD_CODING_ERROR:          "USSD coding error",
+	ERROR_USSD_AT_SEND_FAILED:        "sending USSD command failed",
+	ERROR_USSD_NET_NO_RETURN:         "no USSD answer from network",
+	ERROR_USSD_NET_OVERTIME:          "USSD network timeout",
+	ERROR_USSD_NET_NOT_SUPPORT_USSD:  "USSD not supported by network",
+
+	ERROR_VOICE_BUSY: "voice call in progress",
+
+	ERROR_WRONG_TOKEN:         "wrong verification token",
+	ERROR_WRONG_SESSION:       "wrong session",
+	ERROR_WRONG_SESSION_TOKEN: "session expired",
+}
+
+// Sentinel errors for the most common device errors, for use with errors.Is.
+// Any *APIError matches the sentinel with the same code.
+var (
+	ErrNotSupported      = newSentinel(ERROR_SYSTEM_NO_SUPPORT)
+	ErrNoRights          = newSentinel(ERROR_SYSTEM_NO_RIGHTS)
+	ErrSystemBusy        = newSentinel(ERROR_SYSTEM_BUSY)
+	ErrFormat            = newSentinel(ERROR_FORMAT_ERROR)
+	ErrParameter         = newSentinel(ERROR_PARAMETER_ERROR)
+	ErrNoSIM             = newSentinel(ERROR_NO_SIM_CARD_OR_INVALID_SIM_CARD)
+	ErrPINRequired       = newSentinel(ERROR_CHECK_SIM_CARD_PIN_LOCK)
+	ErrPUKRequired       = newSentinel(ERROR_CHECK_SIM_CARD_PUK_LOCK)
+	ErrWrongPIN          = newSentinel(ERROR_DEVICE_PIN_VALIDATE_FAILED)
+	ErrWrongPUK          = newSentinel(ERROR_DEVICE_PUK_MODIFFY_FAILED)
+	ErrSIMBusy           = newSentinel(ERROR_DEVICE_SIM_CARD_BUSY)
+	ErrWrongUsername     = newSentinel(ERROR_LOGIN_USERNAME_WRONG)
+	ErrWrongPassword     = newSentinel(ERROR_LOGIN_PASSWORD_WRONG)
+	ErrAlreadyLoggedIn   = newSentinel(ERROR_LOGIN_ALREADY_LOGIN)
+	ErrTooManyUsers      = newSentinel(ERROR_LOGIN_TOO_MANY_USERS_LOGINED)
+	ErrWrongCredentials  = newSentinel(ERROR_LOGIN_USERNAME_PWD_WRONG)
+	ErrLoginLocked       = newSentinel(ERROR_LOGIN_USERNAME_PWD_ORERRUN)
+	ErrSMSStorageFull    = newSentinel(ERROR_SMS_STORAGE_FULL)
+	ErrSMSNoLocalSpace   = newSentinel(ERROR_SMS_LOCAL_SPACE_NOT_ENOUGH)
+	ErrVoiceBusy         = newSentinel(ERROR_VOICE_BUSY)
+	ErrWrongToken        = newSentinel(ERROR_WRONG_TOKEN)
+	ErrWrongSession      = newSentinel(ERROR_WRONG_SESSION)
+	ErrWrongSessionToken = newSentinel(ERROR_WRONG_SESSION_TOKEN)
+)
+
+// APIError is returned when the device answers a request with an <error>
+// response.
+type APIError struct {
+	// Code is the numeric error code reported by the device.
+	Code int
+	// Message is a human readable description of Code.
+	Message string
+	// Endpoint is the API path of the failed request, empty for sentinels.
+	Endpoint string
+}
+
+func (e *APIError) Error() string {
+	if e.Endpoint == "" {
+		return fmt.Sprintf("huawei: %s (code %d)", e.Message, e.Code)
+	}
+	return fmt.Sprintf("huawei: %s: %s (code %d)", e.Endpoint, e.Message, e.Code)
+}
+
+// Is reports whether target is an *APIError with the same code, so that
+// errors.Is(err, ErrWrongPassword) matches regardless of the endpoint.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// ErrorMessage returns the human readable message for a device error code.
+func ErrorMessage(code int) string {
+	if msg, ok := errorMessages[code]; ok {
+		return msg
+	}
+	return "unknown error code " + strconv.Itoa(code)
+}
+
+func newSentinel(code int) *APIError {
+	return &APIError{Code: code, Message: ErrorMessage(code)}
+}
+
+// checkResponse returns an *APIError if body is an <error> response to a
+// request on endpoint, and nil otherwise.
+func checkResponse(endpoint string, body []byte) error {
+	var errResp struct {
+		XMLName xml.Name `xml:"error"`
+		Code    string   `xml:"code"`
+		Message string   `xml:"message"`
+	}
+	if err := xml.Unmarshal(body, &errResp); err != nil {
+		return nil
+	}
+
+	code, _ := strconv.Atoi(strings.TrimSpace(errResp.Code))
+	msg := ErrorMessage(code)
+	if _, known := errorMessages[code]; !known && strings.TrimSpace(errResp.Message) != "" {
+		msg = strings.TrimSpace(errResp.Message)
+	}
+	return &APIError{Code: code, Message: msg, Endpoint: endpoint}
+}
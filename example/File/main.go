@@ -2,30 +2,15 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
-	"time"
-	"unicode/utf8"
 
 	"github.com/XigmaDev/huawei"
+	"github.com/XigmaDev/huawei/sms"
 )
 
-func calculateSMSParts(charCount int) int {
-	if charCount <= 70 {
-		return 1 // First page: 70 characters
-	} else if charCount <= 70+64 {
-		return 2 // Second page: 64 characters (total 134)
-	} else if charCount <= 70+64+67 {
-		return 3 // Third page: 67 characters (total 201)
-	} else {
-		// After third page, each additional page is 67 characters
-		remainingChars := charCount - (70 + 64)       // Subtract first two pages
-		additionalPages := (remainingChars + 66) / 67 // Ceiling division for pages of 67
-		return 2 + additionalPages                    // Add the first two pages
-	}
-}
-
 func main() {
 	// Read the message from message.txt
 	message, err := os.ReadFile("message.txt")
@@ -34,9 +19,8 @@ func main() {
 		return
 	}
 	msg := string(message)
-	// Count the number of characters (not bytes) in the message
-	charCount := utf8.RuneCountInString(msg)
-	fmt.Println("Message Character: ", charCount)
+	info := sms.Analyze(msg)
+	fmt.Println("Message Length: ", info.Length, info.Encoding)
 	// Read phone numbers from number.txt
 	file, err := os.Open("number.txt")
 	if err != nil {
@@ -59,7 +43,7 @@ func main() {
 	}
 	numRecipients := len(phoneNumbers)
 
-	smsPartsPerMessage := calculateSMSParts(charCount)
+	smsPartsPerMessage := info.Segments
 	fmt.Println("SMS PART : ", smsPartsPerMessage)
 	// Calculate total SMS to be sent
 	totalSMS := numRecipients * smsPartsPerMessage
@@ -71,15 +55,23 @@ func main() {
 	}
 
 	// Login to Huawei modem
-	h := huawei.NewHuawei("http://192.168.8.1")
-	if err := h.Login("admin", "admin"); err != nil {
+	h, err := huawei.NewHuawei("192.168.8.1")
+	if err != nil {
+		fmt.Println("Invalid device address:", err)
+		return
+	}
+	ctx := context.Background()
+	if err := h.Login(ctx, "admin", "admin"); err != nil {
 		fmt.Println("Login failed:", err)
 		return
 	}
 
 	// Send messages to all phone numbers
-	for _, phone := range phoneNumbers {
-		h.SendSMS(msg, phone)
-		time.Sleep(3 * time.Second)
+	statuses, err := h.SendSMSMulti(ctx, msg, phoneNumbers)
+	for _, s := range statuses {
+		fmt.Println(s.Phone, s.Outcome)
+	}
+	if err != nil {
+		fmt.Println("Sending failed:", err)
 	}
 }
@@ -1,8 +1,8 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"time"
 
 	"github.com/XigmaDev/huawei"
 )
@@ -30,17 +30,25 @@ func main() {
 		"09339758240", "09339428633", "09156709645", "09153343508", "09155345761",
 	}
 
-	h := huawei.NewHuawei("http://192.168.8.1")
+	h, err := huawei.NewHuawei("192.168.8.1")
+	if err != nil {
+		fmt.Println("Invalid device address:", err)
+		return
+	}
 
-	if err := h.Login("admin", "admin"); err != nil {
+	ctx := context.Background()
+	if err := h.Login(ctx, "admin", "admin"); err != nil {
 		fmt.Println("Login failed:", err)
 		return
 	}
 
 	msg := "یادآوری واکسن مننژیت امشب ساعت ۱۹:۳۰ در هلال احمر بشرویه لطفاً به\u200cموقع مراجعه فرمایید. عسکری"
 	print(len(msg))
-	for _, phone := range umrah1403 {
-		h.SendSMS(msg, phone)
-		time.Sleep(3 * time.Second)
+	statuses, err := h.SendSMSMulti(ctx, msg, umrah1403)
+	for _, s := range statuses {
+		fmt.Println(s.Phone, s.Outcome)
+	}
+	if err != nil {
+		fmt.Println("Sending failed:", err)
 	}
 }
@@ -0,0 +1,314 @@
+package huawei
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDevice fakes the HiLink API of a device for tests. It keeps a
+// session and verification tokens the way firmware does, stores messages,
+// and records every request. Like real firmware it cannot handle two
+// requests at once, and counts any that overlap.
+//
+// Endpoints behave as on a device that needs no login unless requireLogin
+// is set; handle overrides or adds endpoints.
+type fakeDevice struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	// Behaviour, set before the first request.
+	noSesTokInfo   bool // firmware without /api/webserver/SesTokInfo
+	noHeaderTokens bool // tokens only come from /api/webserver/token
+	oneTimeTokens  bool // every token is accepted only once
+	requireLogin   bool // requests other than the login exchange need a login
+
+	inFlight atomic.Int32
+	overlaps atomic.Int32
+
+	mu           sync.Mutex
+	handlers     map[string]http.HandlerFunc
+	requests     map[string]int
+	nextID       int
+	session      string
+	loggedIn     bool
+	tokens       map[string]bool
+	rejectTokens int       // number of valid tokens to reject anyway
+	messages     []fakeSMS // newest first, as the device lists them
+	sent         []string  // every phone a message was sent to
+}
+
+// fakeSMS is a message stored on a fakeDevice.
+type fakeSMS struct {
+	Index  int
+	Phone  string
+	Text   string
+	Date   string
+	Unread bool
+}
+
+// fakeMessages returns n read messages with indexes from 40000 on.
+func fakeMessages(n int) []fakeSMS {
+	messages := make([]fakeSMS, n)
+	for i := range messages {
+		messages[i] = fakeSMS{Index: 40000 + i, Phone: "+15550100", Text: "hi", Date: "2024-01-02 03:04:05"}
+	}
+	return messages
+}
+
+func newFakeDevice(t *testing.T) *fakeDevice {
+	d := &fakeDevice{
+		t:        t,
+		handlers: make(map[string]http.HandlerFunc),
+		requests: make(map[string]int),
+		tokens:   make(map[string]bool),
+	}
+	d.session = d.newID("session")
+	d.srv = httptest.NewServer(d)
+	t.Cleanup(d.srv.Close)
+	return d
+}
+
+// URL returns the address of the device.
+func (d *fakeDevice) URL() string {
+	return d.srv.URL
+}
+
+// handle makes the device answer requests on path with fn.
+func (d *fakeDevice) handle(path string, fn http.HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[path] = fn
+}
+
+// count returns the number of requests the device received on path.
+func (d *fakeDevice) count(path string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.requests[path]
+}
+
+// setMessages replaces the stored messages, newest first.
+func (d *fakeDevice) setMessages(messages ...fakeSMS) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = messages
+}
+
+// unread returns the indexes of the unread messages.
+func (d *fakeDevice) unread() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var indexes []int
+	for _, m := range d.messages {
+		if m.Unread {
+			indexes = append(indexes, m.Index)
+		}
+	}
+	return indexes
+}
+
+// reboot drops the session and every token, as a restart of the device does.
+func (d *fakeDevice) reboot() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.session = d.newID("session")
+	d.loggedIn = false
+	d.tokens = make(map[string]bool)
+}
+
+func (d *fakeDevice) newID(prefix string) string {
+	d.nextID++
+	return fmt.Sprint(prefix, d.nextID)
+}
+
+func (d *fakeDevice) newToken() string {
+	token := d.newID("token")
+	d.tokens[token] = true
+	return token
+}
+
+func (d *fakeDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if d.inFlight.Add(1) > 1 {
+		d.overlaps.Add(1)
+	}
+	defer d.inFlight.Add(-1)
+	// Widen the window in which overlapping requests would be caught.
+	time.Sleep(50 * time.Microsecond)
+
+	d.mu.Lock()
+	d.requests[r.URL.Path]++
+	handler := d.handlers[r.URL.Path]
+	d.mu.Unlock()
+	if handler != nil {
+		handler(w, r)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if code := d.check(w, r); code != 0 {
+		fmt.Fprintf(w, `<error><code>%d</code><message></message></error>`, code)
+		return
+	}
+	if !d.noHeaderTokens {
+		w.Header().Set("__RequestVerificationToken", d.newToken())
+	}
+	d.serve(w, r)
+}
+
+// check verifies the session, login and token of a request and returns the
+// error code the device answers with, or 0.
+func (d *fakeDevice) check(w http.ResponseWriter, r *http.Request) int {
+	path := r.URL.Path
+	if path == "/api/webserver/SesTokInfo" || path == "/api/webserver/token" {
+		return 0
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	switch {
+	case err != nil:
+		// Firmware hands out a session to clients without one.
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: d.session, Path: "/"})
+	case cookie.Value != d.session:
+		return ERROR_WRONG_SESSION
+	}
+
+	if r.Method == http.MethodPost {
+		token := r.Header.Get("__RequestVerificationToken")
+		if !d.tokens[token] {
+			return ERROR_WRONG_TOKEN
+		}
+		if d.rejectTokens > 0 {
+			d.rejectTokens--
+			return ERROR_WRONG_TOKEN
+		}
+		if d.oneTimeTokens {
+			delete(d.tokens, token)
+		}
+	}
+
+	if d.requireLogin && !d.loggedIn && path != "/api/user/state-login" && path != "/api/user/login" {
+		return ERROR_SYSTEM_NO_RIGHTS
+	}
+	return 0
+}
+
+// serve answers a request that passed check.
+func (d *fakeDevice) serve(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/webserver/SesTokInfo":
+		if d.noSesTokInfo {
+			fmt.Fprint(w, `<error><code>100002</code><message></message></error>`)
+			return
+		}
+		fmt.Fprintf(w, `<response><SesInfo>SessionID=%s</SesInfo><TokInfo>%s</TokInfo></response>`, d.session, d.newToken())
+	case "/api/webserver/token":
+		fmt.Fprintf(w, `<response><token>%s</token></response>`, d.newToken())
+	case "/api/user/state-login":
+		fmt.Fprint(w, `<response><State>-1</State><Username></Username><password_type>4</password_type></response>`)
+	case "/api/user/login":
+		// The device promotes the session on login.
+		d.session = d.newID("session")
+		d.loggedIn = true
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: d.session, Path: "/"})
+		fmt.Fprint(w, `<response>OK</response>`)
+	case "/api/monitoring/check-notifications":
+		fmt.Fprintf(w, `<response><UnreadMessage>%d</UnreadMessage><SmsStorageFull>0</SmsStorageFull></response>`, d.unreadLocked())
+	case "/api/sms/sms-count":
+		fmt.Fprintf(w, `<response><LocalUnread>%d</LocalUnread><LocalInbox>%d</LocalInbox><LocalOutbox>0</LocalOutbox><LocalDraft>0</LocalDraft><LocalDeleted>0</LocalDeleted><SimUnread>0</SimUnread><SimInbox>0</SimInbox><SimOutbox>0</SimOutbox><SimDraft>0</SimDraft><LocalMax>500</LocalMax><SimMax>50</SimMax></response>`,
+			d.unreadLocked(), len(d.messages))
+	case "/api/sms/sms-list":
+		var req struct {
+			PageIndex int `xml:"PageIndex"`
+			ReadCount int `xml:"ReadCount"`
+		}
+		d.decode(r, &req)
+		if req.ReadCount > 50 {
+			d.t.Errorf("ReadCount = %d, above the firmware cap", req.ReadCount)
+		}
+		// Most firmware caps pages at 50 messages.
+		size := min(req.ReadCount, 50)
+		fmt.Fprintf(w, `<response><Count>%d</Count><Messages>`, len(d.messages))
+		for i := (req.PageIndex - 1) * size; i >= 0 && i < min(req.PageIndex*size, len(d.messages)); i++ {
+			m := d.messages[i]
+			stat := 1
+			if m.Unread {
+				stat = 0
+			}
+			fmt.Fprintf(w, `<Message><Smstat>%d</Smstat><Index>%d</Index><Phone>%s</Phone><Content>%s</Content><Date>%s</Date><Sca></Sca><SaveType>4</SaveType><Priority>0</Priority><SmsType>1</SmsType></Message>`,
+				stat, m.Index, m.Phone, m.Text, m.Date)
+		}
+		fmt.Fprint(w, `</Messages></response>`)
+	case "/api/sms/set-read":
+		var req struct {
+			Index []int `xml:"Index"`
+		}
+		d.decode(r, &req)
+		for i := range d.messages {
+			for _, index := range req.Index {
+				if d.messages[i].Index == index {
+					d.messages[i].Unread = false
+				}
+			}
+		}
+		fmt.Fprint(w, `<response>OK</response>`)
+	case "/api/sms/delete-sms":
+		var req struct {
+			Index []int `xml:"Index"`
+		}
+		d.decode(r, &req)
+		kept := d.messages[:0]
+		for _, m := range d.messages {
+			deleted := false
+			for _, index := range req.Index {
+				deleted = deleted || m.Index == index
+			}
+			if !deleted {
+				kept = append(kept, m)
+			}
+		}
+		d.messages = kept
+		fmt.Fprint(w, `<response>OK</response>`)
+	case "/api/sms/send-sms":
+		var req struct {
+			Phones []string `xml:"Phones>Phone"`
+		}
+		d.decode(r, &req)
+		d.sent = append(d.sent, req.Phones...)
+		fmt.Fprint(w, `<response>OK</response>`)
+	case "/api/sms/send-status":
+		// Report every message ever sent as delivered.
+		fmt.Fprintf(w, `<response><Phone></Phone><SucPhone>%s</SucPhone><FailPhone></FailPhone><TotalCount>%d</TotalCount><CurIndex>%d</CurIndex></response>`,
+			strings.Join(d.sent, ";"), len(d.sent), len(d.sent))
+	case "/api/dialup/dial":
+		fmt.Fprint(w, `<response>OK</response>`)
+	default:
+		fmt.Fprint(w, `<error><code>100002</code><message></message></error>`)
+	}
+}
+
+func (d *fakeDevice) unreadLocked() int {
+	n := 0
+	for _, m := range d.messages {
+		if m.Unread {
+			n++
+		}
+	}
+	return n
+}
+
+// decode unmarshals the body of r into v.
+func (d *fakeDevice) decode(r *http.Request, v any) {
+	body, _ := io.ReadAll(r.Body)
+	if err := xml.Unmarshal(body, v); err != nil {
+		d.t.Errorf("decoding %s request: %v", r.URL.Path, err)
+	}
+}
@@ -1,43 +1,77 @@
 package huawei
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
+	"crypto/tls"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/XigmaDev/huawei/sms"
 )
 
+// Huawei is a client for a HiLink device.
+//
+// A Huawei is safe for concurrent use by multiple goroutines. Requests are
+// queued and sent to the device one at a time, since the firmware does not
+// cope with parallel requests; callers simply block until their turn comes
+// or their context is done.
 type Huawei struct {
-	IP     string
-	client *http.Client
-	token  string
+	IP        string
+	client    *http.Client
+	logger    atomic.Pointer[slog.Logger]
+	userAgent string
+	tls       *tls.Config
+	transport http.RoundTripper
+	timeout   *time.Duration
+	retry     RetryPolicy
+	location  *time.Location
+	optimizer *sms.Optimizer
+
+	// queue serializes access to the device. The token and session state
+	// below may only be touched while holding it.
+	queue deviceQueue
+
+	// tokens queues the verification tokens handed out by the device;
+	// lastToken is the most recently used one, reused by firmware that
+	// does not rotate tokens.
+	tokens    []string
+	lastToken string
+
+	// authMu serializes logins, so that concurrent requests failing on the
+	// same expired session log in again only once.
+	authMu sync.Mutex
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// username and password are remembered by Login when keepCredentials
+	// is set, and used to log in again once the device drops the session.
+	keepCredentials bool
+	username        string
+	password        string
+	onReauth        func(ReauthEvent)
+	// logins counts successful logins, so a request can tell whether the
+	// session was renewed since it was sent.
+	logins uint64
 }
 
 type ErrorResponse struct {
 	XMLName xml.Name
 }
 
-type SMS struct {
-	Smstat   string `xml:"Smstat"`
-	Index    string `xml:"Index"`
-	Phone    string `xml:"Phone"`
-	Content  string `xml:"Content"`
-	Date     string `xml:"Date"`
-	Sca      string `xml:"Sca"`
-	SaveType string `xml:"SaveType"`
-	Priority string `xml:"Priority"`
-	SmsType  string `xml:"SmsType"`
-}
-
 type SMSListResponse struct {
 	XMLName  xml.Name `xml:"response"`
 	Count    int      `xml:"Count"`
-	Messages []SMS    `xml:"Messages>Message"`
+	Messages []RawSMS `xml:"Messages>Message"`
 }
 
 type SMSCountResponse struct {
@@ -55,25 +89,57 @@ type SMSCountResponse struct {
 	SimMax       string   `xml:"SimMax"`
 }
 
-type ConnectionStatusResponse struct {
-	XMLName              xml.Name `xml:"response"`
-	ConnectionStatus     string   `xml:"ConnectionStatus"`
-	SignalStrength       string   `xml:"SignalStrength"`
-	SignalIcon           string   `xml:"SignalIcon"`
-	CurrentNetworkType   string   `xml:"CurrentNetworkType"`
-	CurrentServiceDomain string   `xml:"CurrentServiceDomain"`
-	RoamingStatus        string   `xml:"RoamingStatus"`
-	BatteryStatus        string   `xml:"BatteryStatus"`
-	BatteryLevel         string   `xml:"BatteryLevel"`
-	SimlockStatus        string   `xml:"SimlockStatus"`
-	WanIPAddress         string   `xml:"WanIPAddress"`
-	PrimaryDNS           string   `xml:"PrimaryDns"`
-	SecondaryDNS         string   `xml:"SecondaryDns"`
-	CurrentWifiUser      string   `xml:"CurrentWifiUser"`
-	TotalWifiUser        string   `xml:"TotalWifiUser"`
-	ServiceStatus        string   `xml:"ServiceStatus"`
-	SimStatus            string   `xml:"SimStatus"`
-	WifiStatus           string   `xml:"WifiStatus"`
+// SMSCount holds the number of messages in each box of the device's local
+// storage and of the SIM card, along with the capacity of both.
+type SMSCount struct {
+	LocalUnread  int // unread messages in local storage
+	LocalInbox   int // messages in the local inbox
+	LocalOutbox  int // messages in the local outbox
+	LocalDraft   int // drafts in local storage
+	LocalDeleted int // messages in the local trash
+	SimUnread    int // unread messages on the SIM card
+	SimInbox     int // messages in the SIM card inbox
+	SimOutbox    int // messages in the SIM card outbox
+	SimDraft     int // drafts on the SIM card
+	LocalMax     int // capacity of local storage
+	SimMax       int // capacity of the SIM card
+}
+
+// LocalUsed returns the number of messages held in local storage.
+func (c *SMSCount) LocalUsed() int {
+	return c.LocalInbox + c.LocalOutbox + c.LocalDraft + c.LocalDeleted
+}
+
+// SimUsed returns the number of messages held on the SIM card.
+func (c *SMSCount) SimUsed() int {
+	return c.SimInbox + c.SimOutbox + c.SimDraft
+}
+
+// LocalFree returns the number of messages local storage can still take.
+func (c *SMSCount) LocalFree() int {
+	return max(c.LocalMax-c.LocalUsed(), 0)
+}
+
+// SimFree returns the number of messages the SIM card can still take.
+func (c *SMSCount) SimFree() int {
+	return max(c.SimMax-c.SimUsed(), 0)
+}
+
+// IsLocalFull reports whether local storage has no room left. Sending
+// fails while it is full, as the device keeps a copy of every sent message.
+func (c *SMSCount) IsLocalFull() bool {
+	return c.LocalMax > 0 && c.LocalFree() == 0
+}
+
+// IsSimFull reports whether the SIM card has no room left.
+func (c *SMSCount) IsSimFull() bool {
+	return c.SimMax > 0 && c.SimFree() == 0
+}
+
+// UnreadTotal returns the number of unread messages in local storage and on
+// the SIM card.
+func (c *SMSCount) UnreadTotal() int {
+	return c.LocalUnread + c.SimUnread
 }
 
 type Response struct {
@@ -81,126 +147,279 @@ type Response struct {
 	Status  string   `xml:",chardata"`
 }
 
-func NewHuawei(ip string) *Huawei {
-	return &Huawei{
-		IP:     ip,
-		client: &http.Client{},
-		token:  "",
-	}
+type tokenResponse struct {
+	XMLName xml.Name `xml:"response"`
+	Token   string   `xml:"token"`
+}
+
+type dialRequest struct {
+	XMLName xml.Name `xml:"request"`
+	Action  int      `xml:"Action"`
+}
+
+type sendSMSRequest struct {
+	XMLName  xml.Name `xml:"request"`
+	Index    int      `xml:"Index"`
+	Phones   []string `xml:"Phones>Phone"`
+	Sca      string   `xml:"Sca"`
+	Content  string   `xml:"Content"`
+	Length   int      `xml:"Length"`
+	Reserved int      `xml:"Reserved"`
+	Date     string   `xml:"Date"`
+}
+
+type smsListRequest struct {
+	XMLName         xml.Name `xml:"request"`
+	PageIndex       int      `xml:"PageIndex"`
+	ReadCount       int      `xml:"ReadCount"`
+	BoxType         int      `xml:"BoxType"`
+	SortType        int      `xml:"SortType"`
+	Ascending       int      `xml:"Ascending"`
+	UnreadPreferred int      `xml:"UnreadPreferred"`
 }
 
-// Login authenticates a user with the provided username and password.
-// It encodes the password in base64, constructs an XML payload, and sends
-// a POST request to the Huawei API endpoint for user login. The function
-// sets necessary headers, including a request verification token obtained
-// from the GetToken method. It reads and parses the XML response to determine
-// the login status and returns an error if authentication fails.
+type deleteSMSRequest struct {
+	XMLName xml.Name `xml:"request"`
+	Index   []int    `xml:"Index"`
+}
+
+// NewHuawei creates a client for the device at addr, which may be a host,
+// a host:port or a full URL such as "https://192.168.8.1". Without a scheme,
+// http is assumed.
 //
 // Parameters:
-//   - username: The username for authentication.
-//   - password: The password for authentication.
+//   - addr: The address of the device.
+//   - opts: Options configuring transport, timeouts, credentials and logging.
 //
 // Returns:
-//   - error: An error if the login process fails, otherwise nil.
-func (h *Huawei) Login(username, password string) error {
-
-	encodedPassword := base64.StdEncoding.EncodeToString([]byte(password))
+//   - *Huawei: The client.
+//   - error: An error if addr is not a valid device address.
+func NewHuawei(addr string, opts ...Option) (*Huawei, error) {
+	baseURL, err := parseBaseURL(addr)
+	if err != nil {
+		return nil, err
+	}
 
-	payload := fmt.Sprintf(`
-			<request>
-				<Username>%s</Username>
-				<Password>%s</Password>
-			</request>`,
-		username, encodedPassword)
+	// cookiejar.New only fails on invalid options.
+	jar, _ := cookiejar.New(nil)
+	h := &Huawei{
+		IP:        baseURL,
+		client:    &http.Client{Jar: jar},
+		userAgent: defaultUserAgent,
+		queue:     queueFor(baseURL),
+		retry:     DefaultRetryPolicy,
+		location:  time.Local,
+	}
+	h.logger.Store(slog.New(slog.DiscardHandler))
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.applyClientOptions()
+	return h, nil
+}
 
-	req, err := http.NewRequest("POST", h.IP+"/api/user/login", bytes.NewReader([]byte(payload)))
-	if err != nil {
-		return err
+// SetLogger routes the client's diagnostics to logger. The client is silent
+// by default; passing nil silences it again.
+func (h *Huawei) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
 	}
+	h.logger.Store(logger)
+}
 
-	req.Header.Add("Accept", "*/*")
-	req.Header.Add("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Add("Cache-Control", "no-cache")
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
-	req.Header.Add("Pragma", "no-cache")
-	req.Header.Add("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36")
-	req.Header.Add("X-Requested-With", "XMLHttpRequest")
+// log returns the logger diagnostics are written to.
+func (h *Huawei) log() *slog.Logger {
+	return h.logger.Load()
+}
 
-	if err := h.GetToken(); err != nil {
+// GetToken retrieves a token from the Huawei web server.
+// It sends a GET request to the /api/webserver/token endpoint and parses the XML response to extract the token.
+// The token replaces any tokens the client has queued.
+// Returns an error if the request fails, the response cannot be read, or the XML cannot be unmarshaled.
+func (h *Huawei) GetToken(ctx context.Context) error {
+	if err := h.queue.acquire(ctx); err != nil {
 		return err
 	}
-	req.Header.Set("__RequestVerificationToken", h.token)
+	defer h.queue.release()
+	return h.getToken(ctx)
+}
 
-	resp, err := h.client.Do(req)
+// getToken is GetToken for callers already holding the device queue.
+func (h *Huawei) getToken(ctx context.Context) error {
+	tokenResp, err := doLocked[tokenResponse](ctx, h, "GET", "/api/webserver/token", "", nil)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	h.tokens = []string{tokenResp.Token}
+	return nil
+}
+
+// do sends req to the device and decodes the answer into a Resp.
+// req is marshaled with encoding/xml and should have a root element named
+// "request"; a nil req sends an empty body. Requests other than GET carry
+// the next request verification token from the client's token queue.
+// If the device rejects the session, for instance after a reboot, a new
+// one is started and the request retried once; if it still fails and
+// credentials are kept, it logs in again and retries once more. Transient failures are retried
+// according to the client's RetryPolicy. An <error> response is returned
+// as *APIError.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - h: The client to send the request with.
+//   - method: The HTTP method to use (e.g., "GET", "POST").
+//   - path: The API path to send the request to.
+//   - req: The request to marshal into the request body, or nil.
+//
+// Returns:
+//   - *Resp: The decoded response.
+//   - error: An error if the request fails, the device answers with an error
+//     or the response cannot be decoded.
+func do[Resp any](ctx context.Context, h *Huawei, method, path string, req any) (*Resp, error) {
+	payload, err := encodeRequest(req)
 	if err != nil {
-		log.Fatalf("Error reading response: %v", err)
+		return nil, err
 	}
-	var res Response
-	if err := xml.Unmarshal(body, &res); err != nil {
-		log.Fatalf("Error parsing XML: %v", err)
+	body, err := h.send(ctx, method, path, payload, isIdempotent(method, path))
+	if err != nil {
+		return nil, err
 	}
+	return decodeResponse[Resp](path, body)
+}
 
-	fmt.Printf("Login Status: %s\n", res.Status)
-
-	if isErrorResponse(body) {
-		return fmt.Errorf("authentication failed")
+// doLocked is do for callers already holding the device queue that pick
+// the verification token themselves, such as the login exchanges. It
+// neither retries nor logs in again.
+func doLocked[Resp any](ctx context.Context, h *Huawei, method, path, token string, req any) (*Resp, error) {
+	payload, err := encodeRequest(req)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	body, err := h.doRequest(ctx, method, path, token, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(path, body); err != nil {
+		return nil, err
+	}
+	return decodeResponse[Resp](path, body)
 }
 
-// GetToken retrieves a token from the Huawei web server.
-// It sends a GET request to the /api/webserver/token endpoint and parses the XML response to extract the token.
-// The token is then stored in the Huawei struct's token field.
-// Returns an error if the request fails, the response cannot be read, or the XML cannot be unmarshaled.
-func (h *Huawei) GetToken() error {
-	req, err := http.NewRequest("GET", h.IP+"/api/webserver/token", nil)
+// encodeRequest marshals req into an XML document, or returns an empty
+// payload for a nil req.
+func encodeRequest(req any) (string, error) {
+	if req == nil {
+		return "", nil
+	}
+	b, err := xml.Marshal(req)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("encoding request: %w", err)
 	}
+	return xml.Header + string(b), nil
+}
 
-	resp, err := h.client.Do(req)
-	if err != nil {
-		return err
+// decodeResponse unmarshals the answer to a request on path into a Resp.
+func decodeResponse[Resp any](path string, body []byte) (*Resp, error) {
+	var resp Resp
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decoding %s response: %w", path, err)
 	}
-	defer resp.Body.Close()
+	return &resp, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+// send sends payload as-is and returns the raw answer, see do. Requests
+// that are not idempotent are not repeated after failures the device may
+// already have acted on.
+func (h *Huawei) send(ctx context.Context, method, url, payload string, idempotent bool) ([]byte, error) {
+	reauthenticated := false
+
+	for attempt := 1; ; {
+		logins := h.loginCount()
+		body, err := h.exchange(ctx, method, url, payload)
+		if err == nil {
+			return body, nil
+		}
+
+		if !reauthenticated && h.reauth(ctx, err, logins) {
+			reauthenticated = true
+			continue
+		}
+		if attempt >= h.retry.MaxAttempts || !h.retry.retryable(err, idempotent) {
+			h.log().Debug("request failed", "endpoint", url, "attempts", attempt, "error", err)
+			return nil, err
+		}
+
+		delay := h.retry.delay(attempt)
+		h.log().Debug("retrying request", "endpoint", url, "attempt", attempt, "delay", delay, "error", err)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		attempt++
 	}
+}
 
-	var tokenResp struct {
-		XMLName xml.Name `xml:"response"`
-		Token   string   `xml:"token"`
+// exchange waits for the device to be free and performs a single request
+// within the current session.
+func (h *Huawei) exchange(ctx context.Context, method, url, payload string) ([]byte, error) {
+	if err := h.queue.acquire(ctx); err != nil {
+		return nil, err
 	}
+	defer h.queue.release()
 
-	if err := xml.Unmarshal(body, &tokenResp); err != nil {
-		return err
+	if err := h.ensureSession(ctx); err != nil {
+		return nil, err
 	}
 
-	h.token = tokenResp.Token
-	return nil
+	// A rejected token is replaced by a fresh one, and a rejected session by
+	// a new one, and the request sent once more; the device does not act on
+	// requests it rejects for either reason.
+	tokenRetried, sessionRenewed := false, false
+	for {
+		var token string
+		if method != http.MethodGet {
+			var err error
+			if token, err = h.nextToken(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		body, err := h.doRequest(ctx, method, url, token, payload)
+		if err != nil {
+			return nil, err
+		}
+		err = checkResponse(url, body)
+		if err == nil {
+			return body, nil
+		}
+		switch {
+		case errors.Is(err, ErrWrongToken) || errors.Is(err, ErrWrongSessionToken):
+			// Do not reuse a token the device rejected.
+			h.tokens, h.lastToken = nil, ""
+			if errors.Is(err, ErrWrongToken) && method != http.MethodGet && !tokenRetried {
+				tokenRetried = true
+				h.log().Debug("token rejected, retrying with a fresh one", "endpoint", url)
+				continue
+			}
+		case (errors.Is(err, ErrWrongSession) || errors.Is(err, ErrNoRights)) && !sessionRenewed:
+			// The device dropped the session, most likely on a reboot.
+			sessionRenewed = true
+			h.log().Debug("session rejected, retrying in a new one", "endpoint", url)
+			if err := h.renewSession(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, err
+	}
 }
 
-// sendRequest sends an HTTP request to the specified URL with the given method and payload.
-// It sets necessary headers and includes a request verification token.
-//
-// Parameters:
-//   - method: The HTTP method to use (e.g., "GET", "POST").
-//   - url: The URL to send the request to.
-//   - payload: The payload to include in the request body.
-//
-// Returns:
-//   - []byte: The response body as a byte slice.
-//   - error: An error if the request fails or if there is an issue reading the response body.
-func (h *Huawei) sendRequest(method, url, payload string) ([]byte, error) {
-	req, err := http.NewRequest(method, h.IP+url, strings.NewReader(xmlEscape(payload)))
+// doRequest sends payload to url as-is with the given verification token,
+// which is omitted when empty. The caller must hold the device queue.
+// The session cookie is kept up to date by the client's cookie jar and the
+// token queue by the verification token headers the device answers with.
+func (h *Huawei) doRequest(ctx context.Context, method, url, token, payload string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.IP+url, strings.NewReader(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -210,48 +429,23 @@ func (h *Huawei) sendRequest(method, url, payload string) ([]byte, error) {
 	req.Header.Add("Cache-Control", "no-cache")
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
 	req.Header.Add("Pragma", "no-cache")
-	req.Header.Add("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36")
+	req.Header.Add("User-Agent", h.userAgent)
 	req.Header.Add("X-Requested-With", "XMLHttpRequest")
-
-	if err := h.GetToken(); err != nil {
-		return nil, err
+	if token != "" {
+		req.Header.Set("__RequestVerificationToken", token)
 	}
-	req.Header.Set("__RequestVerificationToken", h.token)
 
+	start := time.Now()
 	resp, err := h.client.Do(req)
 	if err != nil {
+		h.log().Debug("request failed", "method", method, "endpoint", url, "error", err)
 		return nil, err
 	}
-
 	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
-}
+	h.log().Debug("request", "method", method, "endpoint", url, "status", resp.StatusCode, "duration", time.Since(start))
 
-// isErrorResponse checks if the provided XML body contains an error response.
-// It unmarshals the XML body into a struct and verifies if the root element is "error".
-// If the unmarshalling fails, it returns false.
-// If the root element is "error", it returns true.
-//
-// Parameters:
-//
-//	body []byte - The XML body to check.
-//
-// Returns:
-//
-//	bool - True if the XML body contains an error response, false otherwise.
-func isErrorResponse(body []byte) bool {
-	var errResp struct {
-		XMLName xml.Name `xml:"error"`
-		Code    string   `xml:"code"`
-	}
-	if err := xml.Unmarshal(body, &errResp); err != nil {
-		return false
-	}
-	fmt.Print(errResp)
-
-	//TODO:error response handlig based on error code
-
-	return errResp.XMLName.Local == "error"
+	h.storeTokens(resp.Header)
+	return io.ReadAll(resp.Body)
 }
 
 // Connect establishes a connection to the Huawei device by sending a POST request
@@ -260,16 +454,9 @@ func isErrorResponse(body []byte) bool {
 //
 // Returns:
 //   - error: An error object if the connection attempt fails, otherwise nil.
-func (h *Huawei) Connect() error {
-	payload := "<request><Action>1</Action></request>"
-	body, err := h.sendRequest("POST", "/api/dialup/dial", payload)
-	if err != nil {
-		return err
-	}
-	if isErrorResponse(body) {
-		return fmt.Errorf("connection failed")
-	}
-	return nil
+func (h *Huawei) Connect(ctx context.Context) error {
+	_, err := do[Response](ctx, h, "POST", "/api/dialup/dial", &dialRequest{Action: 1})
+	return err
 }
 
 // Disconnect sends a request to disconnect the Huawei device.
@@ -279,263 +466,110 @@ func (h *Huawei) Connect() error {
 //
 // Returns an error if the request fails or if the response
 // indicates a failure to disconnect.
-func (h *Huawei) Disconnect() error {
-	payload := "<request><Action>0</Action></request>"
-	body, err := h.sendRequest("POST", "/api/dialup/dial", payload)
-	if err != nil {
-		return err
-	}
-	if isErrorResponse(body) {
-		return fmt.Errorf("disconnection failed")
-	}
-	return nil
+func (h *Huawei) Disconnect(ctx context.Context) error {
+	_, err := do[Response](ctx, h, "POST", "/api/dialup/dial", &dialRequest{Action: 0})
+	return err
 }
 
 // SendSMS sends an SMS message to a specified phone number using the Huawei API.
 // It constructs an XML payload with the message details and sends an HTTP POST request.
+// The device accepting the request does not mean the message went out; use
+// Wait on the result to learn whether it did.
 //
 // Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
 //   - msg: The message content to be sent.
 //   - phone: The recipient's phone number.
 //
 // Returns:
-//   - error: An error if the SMS sending fails, otherwise nil.
-func (h *Huawei) SendSMS(msg, phone string) error {
-	url := "/api/sms/send-sms"
-	date := time.Now().Format("2006-01-02 15:04:05")
-	payload := fmt.Sprintf(`
-			<request>
-			<Index>-1</Index>
-				<Phones>
-					<Phone>%s</Phone>
-				</Phones>
-				<Sca></Sca>
-				<Content>%s</Content>
-				<Length>%d</Length>
-				<Reserved>1</Reserved>
-				<Date>%s</Date>
-			</request>`,
-		xmlEscape(phone), xmlEscape(msg), len(msg), xmlEscape(date))
-
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", h.IP+url, strings.NewReader(payload))
-
-	if err != nil {
-		fmt.Println(err)
-	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
-	req.Header.Add("__RequestVerificationToken", h.token)
-
-	res, err := client.Do(req)
-	if err != nil {
-		fmt.Println(err)
-	}
-	defer res.Body.Close()
+//   - *SendResult: The pending send, to be awaited with Wait.
+//   - error: An error if the device does not accept the message.
+func (h *Huawei) SendSMS(ctx context.Context, msg, phone string) (*SendResult, error) {
+	return h.sendSMS(ctx, h.newSMSRequest(-1, msg, []string{phone}))
+}
 
-	_, err = io.ReadAll(res.Body)
-	if err != nil {
-		fmt.Println(err)
+// newSMSRequest builds the request sending or saving msg to phones, stamped
+// with the current time of the device and rewritten by the optimizer set
+// with WithTextOptimizer, if any. index is -1 for a new message or the
+// index of the draft it replaces.
+func (h *Huawei) newSMSRequest(index int, msg string, phones []string) *sendSMSRequest {
+	if h.optimizer != nil {
+		msg = h.optimizer.Optimize(msg)
+	}
+	return &sendSMSRequest{
+		Index:    index,
+		Phones:   phones,
+		Content:  msg,
+		Length:   sms.Length(msg),
+		Reserved: 1,
+		Date:     time.Now().In(h.location).Format(smsDateLayout),
 	}
-	fmt.Println("%s OK", phone)
-
-	// body, err := h.sendRequest("POST", "/api/sms/send-sms", payload)
-	// if err != nil {
-	// 	return err
-	// }
-	// if isErrorResponse(body) {
-	// 	return fmt.Errorf("failed to sending to %s", phone)
-	// }
-	// fmt.Println("%s Sending OK", phone)
-
-	return nil
 }
 
 // GetSmsCount retrieves the count of SMS messages from the Huawei device.
 // It sends a GET request to the /api/sms/sms-count endpoint and parses the response.
 //
-// Returns a slice of strings containing the counts of various SMS categories:
-// - LocalUnread: Count of unread messages in local storage.
-// - LocalInbox: Count of messages in the local inbox.
-// - LocalOutbox: Count of messages in the local outbox.
-// - LocalDraft: Count of draft messages in local storage.
-// - LocalDeleted: Count of deleted messages in local storage.
-// - SimUnread: Count of unread messages on the SIM card.
-// - SimInbox: Count of messages in the SIM card inbox.
-// - SimOutbox: Count of messages in the SIM card outbox.
-// - SimDraft: Count of draft messages on the SIM card.
-// - LocalMax: Maximum number of messages that can be stored locally.
-// - SimMax: Maximum number of messages that can be stored on the SIM card.
-//
-// Returns an error if the request fails or the response cannot be parsed.
-func (h *Huawei) GetSmsCount() ([]string, error) {
-	body, err := h.sendRequest("GET", "/api/sms/sms-count", "")
+// Returns:
+//   - *SMSCount: The message counts and storage limits of the device and SIM card.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (h *Huawei) GetSmsCount(ctx context.Context) (*SMSCount, error) {
+	resp, err := do[SMSCountResponse](ctx, h, "GET", "/api/sms/sms-count", nil)
 	if err != nil {
 		return nil, err
 	}
-	if isErrorResponse(body) {
-		return nil, fmt.Errorf("get SMS count failed")
-	}
-
-	var resp SMSCountResponse
-	if err := xml.Unmarshal(body, &resp); err != nil {
-		return nil, err
-	}
 
-	return []string{
-		resp.LocalUnread,
-		resp.LocalInbox,
-		resp.LocalOutbox,
-		resp.LocalDraft,
-		resp.LocalDeleted,
-		resp.SimUnread,
-		resp.SimInbox,
-		resp.SimOutbox,
-		resp.SimDraft,
-		resp.LocalMax,
-		resp.SimMax,
+	return &SMSCount{
+		LocalUnread:  atoi(resp.LocalUnread),
+		LocalInbox:   atoi(resp.LocalInbox),
+		LocalOutbox:  atoi(resp.LocalOutbox),
+		LocalDraft:   atoi(resp.LocalDraft),
+		LocalDeleted: atoi(resp.LocalDeleted),
+		SimUnread:    atoi(resp.SimUnread),
+		SimInbox:     atoi(resp.SimInbox),
+		SimOutbox:    atoi(resp.SimOutbox),
+		SimDraft:     atoi(resp.SimDraft),
+		LocalMax:     atoi(resp.LocalMax),
+		SimMax:       atoi(resp.SimMax),
 	}, nil
 }
 
-// GetSmsList retrieves a list of SMS messages from the Huawei device.
-// It sends a POST request to the /api/sms/sms-list endpoint with the necessary payload.
-// The function returns a slice of SMS messages and an error if any occurred during the process.
+// GetSmsList retrieves the newest 20 messages of the local inbox.
+// See ListSMS and AllSMS for other boxes and further pages.
 //
 // Returns:
 //   - []SMS: A slice of SMS messages retrieved from the device.
 //   - error: An error if the request failed or the response could not be unmarshaled.
-func (h *Huawei) GetSmsList() ([]SMS, error) {
-	payload := `<request>
-		<PageIndex>1</PageIndex>
-		<ReadCount>20</ReadCount>
-		<BoxType>1</BoxType>
-		<SortType>0</SortType>
-		<Ascending>0</Ascending>
-		<UnreadPreferred>0</UnreadPreferred>
-	</request>`
-
-	body, err := h.sendRequest("POST", "/api/sms/sms-list", payload)
-	if err != nil {
-		return nil, err
-	}
-	if isErrorResponse(body) {
-		return nil, fmt.Errorf("get SMS list failed")
-	}
-
-	var resp SMSListResponse
-	if err := xml.Unmarshal(body, &resp); err != nil {
-		return nil, err
-	}
-	return resp.Messages, nil
+func (h *Huawei) GetSmsList(ctx context.Context) ([]SMS, error) {
+	messages, _, err := h.ListSMS(ctx, ListOptions{Box: Inbox})
+	return messages, err
 }
 
-// DeleteSMS deletes an SMS message from the Huawei device by its index.
-// It sends a POST request to the "/api/sms/delete-sms" endpoint with the specified index.
+// DeleteSMS deletes messages from the Huawei device by their index.
+// It sends POST requests to the "/api/sms/delete-sms" endpoint, each
+// carrying up to DeleteBatchSize indexes; calling it without indexes does
+// nothing.
 //
 // Parameters:
-//   - index: The index of the SMS message to be deleted.
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - indexes: The indexes of the messages to be deleted, see Indexes.
 //
 // Returns:
-//   - error: An error if the request fails or if the response indicates a failure.
-func (h *Huawei) DeleteSMS(index int) error {
-	payload := fmt.Sprintf("<request><Index>%d</Index></request>", index)
-	body, err := h.sendRequest("POST", "/api/sms/delete-sms", payload)
-	if err != nil {
-		return err
-	}
-	if isErrorResponse(body) {
-		return fmt.Errorf("delete SMS failed")
-	}
-	return nil
+//   - error: An error if a request fails or if the response indicates a
+//     failure. Batches sent before the failing one stay deleted.
+func (h *Huawei) DeleteSMS(ctx context.Context, indexes ...int) error {
+	_, err := h.deleteSMS(ctx, indexes)
+	return err
 }
 
-// GetConnectionStatus retrieves the current connection status from the Huawei device.
-// It sends a GET request to the /api/monitoring/status endpoint and parses the XML response.
-//
-// Returns a slice of strings containing various connection status details, or an error if the request fails or the response cannot be parsed.
-//
-// The returned slice contains the following elements in order:
-// - ConnectionStatus
-// - SignalStrength
-// - SignalIcon
-// - CurrentNetworkType
-// - CurrentServiceDomain
-// - RoamingStatus
-// - BatteryStatus
-// - BatteryLevel
-// - SimlockStatus
-// - WanIPAddress
-// - PrimaryDNS
-// - SecondaryDNS
-// - CurrentWifiUser
-// - TotalWifiUser
-// - ServiceStatus
-// - SimStatus
-// - WifiStatus
-//
-// Returns:
-// - []string: A slice of strings containing the connection status details.
-// - error: An error if the request fails or the response cannot be parsed.
-func (h *Huawei) GetConnectionStatus() ([]string, error) {
-	body, err := h.sendRequest("GET", "/api/monitoring/status", "")
-	if err != nil {
-		return nil, err
-	}
-	if isErrorResponse(body) {
-		return nil, fmt.Errorf("get connection status failed")
-	}
-
-	var resp ConnectionStatusResponse
-	if err := xml.Unmarshal(body, &resp); err != nil {
-		return nil, err
-	}
-
-	return []string{
-		resp.ConnectionStatus,
-		resp.SignalStrength,
-		resp.SignalIcon,
-		resp.CurrentNetworkType,
-		resp.CurrentServiceDomain,
-		resp.RoamingStatus,
-		resp.BatteryStatus,
-		resp.BatteryLevel,
-		resp.SimlockStatus,
-		resp.WanIPAddress,
-		resp.PrimaryDNS,
-		resp.SecondaryDNS,
-		resp.CurrentWifiUser,
-		resp.TotalWifiUser,
-		resp.ServiceStatus,
-		resp.SimStatus,
-		resp.WifiStatus,
-	}, nil
-}
-
-// IsConnected checks the connection status of the Huawei device.
-// It returns true if the device is connected (status code "901"), otherwise false.
-// If there is an error retrieving the connection status, it returns false along with the error.
-func (h *Huawei) IsConnected() (bool, error) {
-	status, err := h.GetConnectionStatus()
-	if err != nil {
-		return false, err
-	}
-	if len(status) == 0 {
-		return false, fmt.Errorf("no connection status received")
-	}
-	return status[0] == "901", nil
-}
-
-// xmlEscape escapes special characters in a string for safe inclusion in XML.
-// It converts characters like <, >, &, ', and " to their corresponding XML escape sequences.
-//
-// Parameters:
-//
-//	s - The input string that needs to be escaped.
-//
-// Returns:
-//
-//	A string with special characters replaced by their XML escape sequences.
-func xmlEscape(s string) string {
-	var b bytes.Buffer
-	xml.EscapeText(&b, []byte(s))
-	return b.String()
+// deleteSMS is DeleteSMS, also returning the number of messages deleted
+// before a batch failed.
+func (h *Huawei) deleteSMS(ctx context.Context, indexes []int) (int, error) {
+	deleted := 0
+	for batch := range slices.Chunk(indexes, DeleteBatchSize) {
+		if _, err := do[Response](ctx, h, "POST", "/api/sms/delete-sms", &deleteSMSRequest{Index: batch}); err != nil {
+			return deleted, err
+		}
+		deleted += len(batch)
+	}
+	return deleted, nil
 }
@@ -0,0 +1,156 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentUse(t *testing.T) {
+	shortenSendStatus(t)
+	d := newFakeDevice(t)
+	d.setMessages(fakeMessages(120)...)
+	h, err := NewHuawei(d.URL(), WithCredentials("admin", "admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			if err := h.Login(ctx, "admin", "admin"); err != nil {
+				t.Errorf("Login: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if count, err := h.GetSmsCount(ctx); err != nil {
+				t.Errorf("GetSmsCount: %v", err)
+			} else if count.LocalFree() != 380 {
+				t.Errorf("LocalFree = %d, want 380", count.LocalFree())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			phone := fmt.Sprintf("0912000%04d", i)
+			result, err := h.SendSMS(ctx, "hi", phone)
+			if err != nil {
+				t.Errorf("SendSMS: %v", err)
+				return
+			}
+			statuses, err := result.Wait(ctx)
+			if err != nil {
+				t.Errorf("Wait: %v", err)
+			} else if statuses[0].Outcome != SendSucceeded {
+				t.Errorf("%s: outcome %v, want %v", phone, statuses[0].Outcome, SendSucceeded)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			n := 0
+			for _, err := range h.AllSMS(ctx, ListOptions{PageSize: 25}) {
+				if err != nil {
+					t.Errorf("AllSMS: %v", err)
+					return
+				}
+				n++
+			}
+			if n != 120 {
+				t.Errorf("AllSMS listed %d messages, want 120", n)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := d.count("/api/sms/send-sms"); n != 8 {
+		t.Errorf("device received %d messages, want 8", n)
+	}
+	if n := d.overlaps.Load(); n > 0 {
+		t.Errorf("device handled %d requests while busy with another", n)
+	}
+}
+
+func TestConcurrentReauth(t *testing.T) {
+	d := newFakeDevice(t)
+	d.requireLogin = true
+	h, err := NewHuawei(d.URL(), WithCredentials("admin", "admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := h.Login(ctx, "admin", "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	var reauths atomic.Int32
+	h.OnReauth(func(ev ReauthEvent) {
+		if ev.Err != nil {
+			t.Errorf("re-login failed: %v", ev.Err)
+		}
+		reauths.Add(1)
+	})
+
+	d.reboot()
+	logins := d.count("/api/user/login")
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := h.GetSmsCount(ctx); err != nil {
+				t.Errorf("GetSmsCount: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := d.count("/api/user/login") - logins; n != 1 {
+		t.Errorf("dropped session caused %d logins, want 1", n)
+	}
+	if n := reauths.Load(); n != 1 {
+		t.Errorf("OnReauth called %d times, want 1", n)
+	}
+	if n := d.overlaps.Load(); n > 0 {
+		t.Errorf("device handled %d requests while busy with another", n)
+	}
+}
+
+func TestReauthHookMayUseClient(t *testing.T) {
+	d := newFakeDevice(t)
+	d.requireLogin = true
+	h, err := NewHuawei(d.URL(), WithCredentials("admin", "admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := h.Login(ctx, "admin", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	h.OnReauth(func(ReauthEvent) {
+		if err := h.Login(ctx, "admin", "admin"); err != nil {
+			t.Errorf("Login from OnReauth: %v", err)
+		}
+	})
+
+	d.reboot()
+	done := make(chan error)
+	go func() {
+		_, err := h.GetSmsCount(ctx)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("GetSmsCount: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnReauth calling Login deadlocked")
+	}
+}
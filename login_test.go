@@ -0,0 +1,64 @@
+package huawei
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestSHA256Password(t *testing.T) {
+	tests := []struct {
+		username, password, token string
+		want                      string
+	}{
+		{"admin", "admin", "abc123", "NjQwZDNlNDI5ZDFiMzg5ZTI0YTM4OGE5ZmI3M2U5ZWJmMWQ3ZmIwMDFhY2E0NzcwNDlhMGJhNTcxYWY5OWEyOA=="},
+		{"admin", "p@ss wörd", "6Q1yGX0lQ8q3Zs3xKp7lXpTQ0pH7Cn0y", "MTIwNWNlMGUyODU0YWUwYTQ4NTI1NmVhNjYzNGMwMGNiNzBjYTBlMzViYWU4YzhlMzE5NGRiZWEyMjNlZDg3Ng=="},
+	}
+	for _, tt := range tests {
+		if got := sha256Password(tt.username, tt.password, tt.token); got != tt.want {
+			t.Errorf("sha256Password(%q, %q, %q) = %q, want %q", tt.username, tt.password, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestSCRAMClientProof(t *testing.T) {
+	tests := []struct {
+		password    string
+		salt        string
+		iterations  int
+		authMessage string
+		want        string
+	}{
+		{"admin", "0123456789abcdef0123456789abcdef", 100, "a1b2,c3d4,c3d4", "551261908d431aaafdcfbbb0319d45ad89121c5a9a2f7e9c71f4061914b803b2"},
+		{"secret", "ffeeddccbbaa99887766554433221100", 1000, "f00d,beef,beef", "16e17b7e7e733579eae02db7c117eca7a3e0e0def78c966eec103931b2c9d7e7"},
+	}
+	for _, tt := range tests {
+		salt, _ := hex.DecodeString(tt.salt)
+		got, err := scramClientProof(tt.password, salt, tt.iterations, tt.authMessage)
+		if err != nil {
+			t.Fatalf("scramClientProof(%q): %v", tt.password, err)
+		}
+		if got != tt.want {
+			t.Errorf("scramClientProof(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestLoginDoesNotGuessPasswordType(t *testing.T) {
+	d := newFakeDevice(t)
+	d.handle("/api/user/state-login", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>busy</html`))
+	})
+
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Login(context.Background(), "admin", "admin"); err == nil {
+		t.Error("Login succeeded although state-login could not be read")
+	}
+	if n := d.count("/api/user/login"); n != 0 {
+		t.Errorf("Login posted %d passwords of a guessed type", n)
+	}
+}
@@ -0,0 +1,188 @@
+package huawei
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/XigmaDev/huawei/sms"
+)
+
+// defaultUserAgent is sent unless WithUserAgent overrides it. Some firmware
+// refuses API calls that do not look like they come from a browser.
+const defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36"
+
+// Option configures a Huawei client created by NewHuawei.
+type Option func(*Huawei)
+
+// WithHTTPClient makes the client send requests through a copy of c.
+// A cookie jar is added to the copy if c has none, since the device session
+// is kept in a cookie. Options that tune the client, such as WithTransport
+// or WithTimeout, apply to the copy whatever their order. A nil c is ignored.
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Huawei) {
+		if c == nil {
+			return
+		}
+		client := *c
+		if client.Jar == nil {
+			client.Jar = h.client.Jar
+		}
+		h.client = &client
+	}
+}
+
+// WithTransport makes the client send requests through rt.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(h *Huawei) {
+		h.transport = rt
+	}
+}
+
+// WithTimeout limits the time a single request to the device may take,
+// including reading the response body. Zero means no limit.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Huawei) {
+		h.timeout = &d
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(h *Huawei) {
+		h.userAgent = userAgent
+	}
+}
+
+// WithCredentials remembers username and password, so that the client logs
+// in on its own whenever the device requires it. See KeepCredentials.
+func WithCredentials(username, password string) Option {
+	return func(h *Huawei) {
+		h.keepCredentials = true
+		h.username, h.password = username, password
+	}
+}
+
+// WithLocation sets the time zone the device clock runs in, used to read
+// the dates of messages and to stamp outgoing ones. It defaults to the
+// local time zone of the host; a nil loc is ignored.
+func WithLocation(loc *time.Location) Option {
+	return func(h *Huawei) {
+		if loc != nil {
+			h.location = loc
+		}
+	}
+}
+
+// WithTextOptimizer rewrites every message sent or saved as a draft with o
+// before it reaches the device, so that messages that can be sent in the
+// GSM 03.38 alphabet are. See sms.Optimizer.
+func WithTextOptimizer(o *sms.Optimizer) Option {
+	return func(h *Huawei) {
+		h.optimizer = o
+	}
+}
+
+// WithLogger routes the client's diagnostics to logger. See SetLogger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Huawei) {
+		h.SetLogger(logger)
+	}
+}
+
+// WithRootCAs makes the client trust the certificates in pool when talking
+// to the device over https, for routers with a self-signed certificate.
+// It only applies when the transport is an *http.Transport.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(h *Huawei) {
+		h.tlsConfig().RootCAs = pool
+	}
+}
+
+// WithInsecureSkipVerify disables verification of the device certificate
+// when talking to it over https.
+// It only applies when the transport is an *http.Transport.
+func WithInsecureSkipVerify() Option {
+	return func(h *Huawei) {
+		h.tlsConfig().InsecureSkipVerify = true
+	}
+}
+
+// tlsConfig returns the TLS configuration collected from the options,
+// creating it on first use.
+func (h *Huawei) tlsConfig() *tls.Config {
+	if h.tls == nil {
+		h.tls = &tls.Config{}
+	}
+	return h.tls
+}
+
+// applyClientOptions installs the transport, timeout and TLS configuration
+// collected from the options on the HTTP client, once every option has
+// chosen it.
+func (h *Huawei) applyClientOptions() {
+	if h.transport != nil {
+		h.client.Transport = h.transport
+	}
+	if h.timeout != nil {
+		h.client.Timeout = *h.timeout
+	}
+	h.applyTLS()
+}
+
+// applyTLS sets the root CAs and certificate verification collected from
+// the options on a copy of the client's transport, keeping the rest of its
+// TLS configuration.
+func (h *Huawei) applyTLS() {
+	if h.tls == nil {
+		return
+	}
+
+	transport, ok := h.client.Transport.(*http.Transport)
+	if h.client.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		h.log().Warn("TLS options ignored for custom transport")
+		return
+	}
+
+	transport = transport.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	if h.tls.RootCAs != nil {
+		transport.TLSClientConfig.RootCAs = h.tls.RootCAs
+	}
+	if h.tls.InsecureSkipVerify {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	h.client.Transport = transport
+}
+
+// parseBaseURL turns a device address given as host, host:port or a full
+// http(s) URL into the base URL API paths are appended to.
+func parseBaseURL(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid device address: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid device address %q: unsupported scheme %q", addr, u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid device address %q: missing host", addr)
+	}
+
+	return u.Scheme + "://" + u.Host + strings.TrimRight(u.Path, "/"), nil
+}
@@ -0,0 +1,66 @@
+package huawei
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientOptionsIgnoreOrder(t *testing.T) {
+	rt := &http.Transport{}
+	base := &http.Client{}
+
+	for name, opts := range map[string][]Option{
+		"client first": {WithHTTPClient(base), WithTimeout(time.Second), WithTransport(rt)},
+		"client last":  {WithTimeout(time.Second), WithTransport(rt), WithHTTPClient(base)},
+	} {
+		h, err := NewHuawei("192.168.8.1", opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if h.client.Timeout != time.Second {
+			t.Errorf("%s: timeout = %v, want 1s", name, h.client.Timeout)
+		}
+		if h.client.Transport != rt {
+			t.Errorf("%s: transport not applied", name)
+		}
+		if h.client.Jar == nil {
+			t.Errorf("%s: cookie jar missing", name)
+		}
+	}
+	if base.Timeout != 0 || base.Transport != nil {
+		t.Error("options modified the caller's client")
+	}
+}
+
+func TestWithHTTPClientNil(t *testing.T) {
+	h, err := NewHuawei("192.168.8.1", WithHTTPClient(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.client == nil || h.client.Jar == nil {
+		t.Error("nil client replaced the default client")
+	}
+}
+
+func TestTLSOptionsKeepTransportConfig(t *testing.T) {
+	rt := &http.Transport{TLSClientConfig: &tls.Config{ServerName: "router.local", MinVersion: tls.VersionTLS12}}
+	pool := x509.NewCertPool()
+
+	h, err := NewHuawei("https://192.168.8.1", WithTransport(rt), WithRootCAs(pool), WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := h.client.Transport.(*http.Transport).TLSClientConfig
+	if cfg.ServerName != "router.local" || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("TLS options dropped the transport's config: %+v", cfg)
+	}
+	if cfg.RootCAs != pool || !cfg.InsecureSkipVerify {
+		t.Error("TLS options not applied")
+	}
+	if rt.TLSClientConfig.RootCAs != nil || rt.TLSClientConfig.InsecureSkipVerify {
+		t.Error("TLS options modified the caller's transport")
+	}
+}
@@ -0,0 +1,36 @@
+package huawei
+
+import (
+	"context"
+	"sync"
+)
+
+// deviceQueue serializes requests to a single device. HiLink firmware
+// mixes up tokens and sessions when it handles requests in parallel, so
+// only one request per device is in flight at any time, even across
+// several clients talking to the same device.
+type deviceQueue chan struct{}
+
+// deviceQueues holds the queue of every device, keyed by base URL.
+var deviceQueues sync.Map
+
+// queueFor returns the queue shared by all clients of the device at baseURL.
+func queueFor(baseURL string) deviceQueue {
+	q, _ := deviceQueues.LoadOrStore(baseURL, make(deviceQueue, 1))
+	return q.(deviceQueue)
+}
+
+// acquire waits for the device to be free, or for ctx to be done.
+func (q deviceQueue) acquire(ctx context.Context) error {
+	select {
+	case q <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the device for the next request.
+func (q deviceQueue) release() {
+	<-q
+}
@@ -0,0 +1,104 @@
+package huawei
+
+import (
+	"context"
+	"errors"
+)
+
+// ReauthEvent describes an automatic re-login triggered by a request that
+// failed because the device dropped the session.
+type ReauthEvent struct {
+	// Endpoint is the API path of the request that failed.
+	Endpoint string
+	// Cause is the device error that triggered the re-login.
+	Cause *APIError
+	// Err is the result of the re-login, nil if it succeeded.
+	Err error
+}
+
+// KeepCredentials controls whether Login remembers the username and password.
+// When enabled, requests that fail because the session expired or was
+// rejected log in again with the remembered credentials and are retried
+// once. Disabling it forgets any remembered credentials.
+func (h *Huawei) KeepCredentials(keep bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keepCredentials = keep
+	if !keep {
+		h.username, h.password = "", ""
+	}
+}
+
+// OnReauth registers fn to be called after every automatic re-login,
+// whether it succeeded or not. Passing nil removes the hook. fn is called
+// once the re-login finished and without any of the client's locks held,
+// so it may call back into the client, for instance to log in again.
+func (h *Huawei) OnReauth(fn func(ReauthEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onReauth = fn
+}
+
+// loginCount returns the number of successful logins so far.
+func (h *Huawei) loginCount() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.logins
+}
+
+// isSessionError reports whether code means the device no longer accepts
+// the current session, so logging in again may fix the request. A rejected
+// token alone is handled by fetching a fresh one, see exchange.
+func isSessionError(code int) bool {
+	switch code {
+	case ERROR_SYSTEM_NO_RIGHTS, ERROR_WRONG_SESSION, ERROR_WRONG_SESSION_TOKEN:
+		return true
+	}
+	return false
+}
+
+// reauth logs in again with the remembered credentials after a request
+// failed with cause. logins is the login count seen before the request was
+// sent; if another goroutine has logged in since, the request is simply
+// retried in the renewed session. It reports whether the request should be
+// retried.
+func (h *Huawei) reauth(ctx context.Context, cause error, logins uint64) bool {
+	var apiErr *APIError
+	if !errors.As(cause, &apiErr) || !isSessionError(apiErr.Code) {
+		return false
+	}
+
+	retry, onReauth, err := h.relogin(ctx, apiErr, logins)
+	// The hook runs without authMu held, as it may call back into the client.
+	if onReauth != nil {
+		onReauth(ReauthEvent{Endpoint: apiErr.Endpoint, Cause: apiErr, Err: err})
+	}
+	return retry
+}
+
+// relogin holds authMu while deciding whether to log in again, and does so
+// unless another goroutine already renewed the session, see reauth. It
+// returns whether the request should be retried and, if it logged in, the
+// hook to report the result to along with the login error.
+func (h *Huawei) relogin(ctx context.Context, cause *APIError, logins uint64) (bool, func(ReauthEvent), error) {
+	h.authMu.Lock()
+	defer h.authMu.Unlock()
+
+	h.mu.Lock()
+	username, password, onReauth, renewed := h.username, h.password, h.onReauth, h.logins != logins
+	h.mu.Unlock()
+	if renewed {
+		return true, nil, nil
+	}
+	if username == "" {
+		return false, nil, nil
+	}
+
+	err := h.loginLocked(ctx, username, password)
+	if err != nil {
+		h.log().Warn("re-login failed", "endpoint", cause.Endpoint, "cause", cause.Code, "error", err)
+	} else {
+		h.log().Info("logged in again", "endpoint", cause.Endpoint, "cause", cause.Code)
+	}
+	return err == nil, onReauth, err
+}
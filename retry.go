@@ -0,0 +1,115 @@
+package huawei
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with transient errors are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first one. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with every
+	// further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. Zero means no cap.
+	MaxDelay time.Duration
+	// Jitter randomizes each delay by up to this fraction of it, in [0, 1].
+	Jitter float64
+	// RetryCodes lists the device error codes that are worth retrying.
+	RetryCodes []int
+}
+
+// DefaultRetryPolicy is the policy used unless WithRetryPolicy overrides it.
+// It retries requests the device rejected because it was busy or had already
+// discarded the token, and requests that failed on the network.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+	RetryCodes:  []int{ERROR_SYSTEM_BUSY, ERROR_WRONG_SESSION_TOKEN},
+}
+
+// NoRetry disables retries.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy sets the policy for retrying requests that fail with
+// transient errors.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(h *Huawei) {
+		h.retry = p
+	}
+}
+
+// nonIdempotent lists the endpoints whose requests must not be repeated if
+// the device may already have acted on them.
+var nonIdempotent = map[string]bool{
+	"/api/sms/send-sms": true,
+	"/api/sms/save-sms": true,
+}
+
+// isIdempotent reports whether a request may safely be sent more than once.
+func isIdempotent(method, endpoint string) bool {
+	return method == "GET" || !nonIdempotent[endpoint]
+}
+
+// retryable reports whether a request that failed with err should be
+// retried. Requests that are not idempotent are only retried when the
+// device provably did not act on them: it rejected them with an error
+// code, or the connection could not even be established.
+func (p RetryPolicy) retryable(err error, idempotent bool) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(p.RetryCodes, apiErr.Code)
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return idempotent
+}
+
+// delay returns how long to wait before the given retry, counted from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	shift := max(retry-1, 0)
+	d := p.BaseDelay << shift
+	if d>>shift != p.BaseDelay {
+		// Doubling overflowed.
+		d = math.MaxInt64
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		j := time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+		if j > 0 && d > math.MaxInt64-j {
+			return math.MaxInt64
+		}
+		d += j
+	}
+	return d
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,131 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	p := RetryPolicy{RetryCodes: []int{ERROR_SYSTEM_BUSY}}
+	dial := &url.Error{Op: "Post", URL: "http://192.168.8.1/api/sms/send-sms",
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+	reset := &url.Error{Op: "Post", URL: "http://192.168.8.1/api/sms/send-sms",
+		Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"busy", newSentinel(ERROR_SYSTEM_BUSY), false, true},
+		{"other device error", newSentinel(ERROR_SYSTEM_NO_SUPPORT), true, false},
+		{"failed dial", dial, false, true},
+		{"connection reset", reset, true, true},
+		{"connection reset, not idempotent", reset, false, false},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true, true},
+		{"unexpected EOF, not idempotent", io.ErrUnexpectedEOF, false, false},
+		{"canceled", context.Canceled, true, false},
+		{"deadline", fmt.Errorf("sending: %w", context.DeadlineExceeded), true, false},
+		{"canceled dial", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: context.Canceled}}, true, false},
+	}
+	for _, tt := range tests {
+		if got := p.retryable(tt.err, tt.idempotent); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     RetryPolicy
+		retry int
+		want  time.Duration
+	}{
+		{"first retry", RetryPolicy{BaseDelay: time.Second}, 1, time.Second},
+		{"doubling", RetryPolicy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"overflow capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 70, 5 * time.Second},
+		{"overflow uncapped", RetryPolicy{BaseDelay: time.Second}, 40, math.MaxInt64},
+		{"shift past 63", RetryPolicy{BaseDelay: 1}, 100, math.MaxInt64},
+		{"no delay", RetryPolicy{}, 100, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.p.delay(tt.retry); got != tt.want {
+			t.Errorf("%s: delay(%d) = %v, want %v", tt.name, tt.retry, got, tt.want)
+		}
+	}
+
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second, Jitter: 0.25}
+	for retry := 1; retry <= 5; retry++ {
+		d := min(time.Second<<(retry-1), 4*time.Second)
+		for range 100 {
+			if got := p.delay(retry); got < d*3/4 || got > d*5/4 {
+				t.Fatalf("delay(%d) = %v, outside %v ± 25%%", retry, got, d)
+			}
+		}
+	}
+	p = RetryPolicy{BaseDelay: time.Second, Jitter: 1}
+	for range 100 {
+		if got := p.delay(100); got < 0 {
+			t.Fatalf("delay(100) = %v with jitter", got)
+		}
+	}
+}
+
+// dialFailure fails the first send-sms request as if the device could not
+// be reached, and passes everything else on.
+type dialFailure struct {
+	failed bool
+}
+
+func (rt *dialFailure) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/api/sms/send-sms" && !rt.failed {
+		rt.failed = true
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSendSMSRetries(t *testing.T) {
+	policy := WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	ctx := context.Background()
+
+	d := newFakeDevice(t)
+	h, err := NewHuawei(d.URL(), policy, WithTransport(&dialFailure{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.SendSMS(ctx, "hi", "0912345678"); err != nil {
+		t.Errorf("SendSMS after a failed dial: %v", err)
+	}
+	if n := d.count("/api/sms/send-sms"); n != 1 {
+		t.Errorf("device received %d messages after a failed dial, want 1", n)
+	}
+
+	d = newFakeDevice(t)
+	// Drop the connection after the device may have sent the message.
+	d.handle("/api/sms/send-sms", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	})
+	h, err = NewHuawei(d.URL(), policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.SendSMS(ctx, "hi", "0912345678"); err == nil {
+		t.Error("SendSMS succeeded on a dropped connection")
+	}
+	if n := d.count("/api/sms/send-sms"); n != 1 {
+		t.Errorf("message sent %d times on a dropped connection, want 1", n)
+	}
+}
@@ -0,0 +1,283 @@
+package huawei
+
+import (
+	"context"
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendStatusIdlePolls is the number of polls reporting no send at all
+// after which Wait gives up on recipients the device never reported.
+const sendStatusIdlePolls = 3
+
+// Timing of Wait, variables so tests can shorten them.
+var (
+	// sendStatusInterval is the delay between two polls of the send status.
+	sendStatusInterval = time.Second
+	// Wait gives up on recipients the device has not reported after
+	// sendStatusTimeout plus sendStatusPerRecipient for each recipient.
+	sendStatusTimeout      = 30 * time.Second
+	sendStatusPerRecipient = 10 * time.Second
+)
+
+type sendStatusResponse struct {
+	XMLName    xml.Name `xml:"response"`
+	Phone      string   `xml:"Phone"`
+	SucPhone   string   `xml:"SucPhone"`
+	FailPhone  string   `xml:"FailPhone"`
+	TotalCount int      `xml:"TotalCount"`
+	CurIndex   int      `xml:"CurIndex"`
+}
+
+// SendOutcome is the delivery state of a message for one recipient, as far
+// as the device knows. The device only reports whether it handed the
+// message to the network, not whether it reached the handset.
+type SendOutcome int
+
+const (
+	SendPending SendOutcome = iota
+	SendSucceeded
+	SendFailed
+	// SendUnknown means the device finished without reporting the recipient.
+	SendUnknown
+)
+
+func (o SendOutcome) String() string {
+	switch o {
+	case SendPending:
+		return "pending"
+	case SendSucceeded:
+		return "sent"
+	case SendFailed:
+		return "failed"
+	case SendUnknown:
+		return "unknown"
+	}
+	return "unknown send outcome " + strconv.Itoa(int(o))
+}
+
+// RecipientStatus is the outcome of a send for one recipient.
+type RecipientStatus struct {
+	Phone   string
+	Outcome SendOutcome
+}
+
+// SendResult tracks a message the device accepted for sending.
+//
+// The device reports the progress of the most recent send only, so the
+// result is reliable as long as no other message is sent from the device
+// before Wait returns.
+type SendResult struct {
+	h          *Huawei
+	recipients []RecipientStatus
+	sent       time.Time
+
+	// baseline is the send status before the message was sent, nil if it
+	// could not be read. Outcomes are only taken from a status differing
+	// from it or showing a send in progress, so that those of an earlier
+	// send are not mistaken for the outcomes of this one. A status that
+	// stays equal to it for sendStatusIdlePolls polls is taken as final,
+	// as sending the same message to the same number again ends in the
+	// same status.
+	baseline *sendStatusResponse
+	fresh    bool
+}
+
+// sendSMS posts req to /api/sms/send-sms and returns a result tracking it.
+func (h *Huawei) sendSMS(ctx context.Context, req *sendSMSRequest) (*SendResult, error) {
+	baseline, err := do[sendStatusResponse](ctx, h, "GET", "/api/sms/send-status", nil)
+	if err != nil {
+		baseline = nil
+	}
+	if _, err := do[Response](ctx, h, "POST", "/api/sms/send-sms", req); err != nil {
+		return nil, err
+	}
+	h.log().Debug("SMS queued", "phones", req.Phones)
+
+	r := &SendResult{
+		h:          h,
+		recipients: make([]RecipientStatus, len(req.Phones)),
+		sent:       time.Now(),
+		baseline:   baseline,
+	}
+	for i, phone := range req.Phones {
+		r.recipients[i] = RecipientStatus{Phone: phone}
+	}
+	return r, nil
+}
+
+// Recipients returns the outcome for each recipient known so far.
+func (r *SendResult) Recipients() []RecipientStatus {
+	return append([]RecipientStatus(nil), r.recipients...)
+}
+
+// Failed returns the recipients the message could not be sent to.
+func (r *SendResult) Failed() []string {
+	var phones []string
+	for _, rs := range r.recipients {
+		if rs.Outcome == SendFailed {
+			phones = append(phones, rs.Phone)
+		}
+	}
+	return phones
+}
+
+// Wait polls the /api/sms/send-status endpoint until the device reports
+// the outcome of the send for every recipient. Recipients the device stops
+// reporting without an outcome, or has not reported after 30 seconds plus
+// 10 seconds per recipient, end up as SendUnknown.
+//
+// Parameters:
+//   - ctx: The context bounding the wait.
+//
+// Returns:
+//   - []RecipientStatus: The outcome for each recipient.
+//   - error: An error if polling fails or ctx is done before every outcome
+//     is known; the outcomes known so far are returned along with it.
+func (r *SendResult) Wait(ctx context.Context) ([]RecipientStatus, error) {
+	deadline := r.sent.Add(sendStatusTimeout + time.Duration(len(r.recipients))*sendStatusPerRecipient)
+	idle, unchanged := 0, 0
+	for {
+		status, err := do[sendStatusResponse](ctx, r.h, "GET", "/api/sms/send-status", nil)
+		if err != nil {
+			return r.Recipients(), err
+		}
+
+		if !r.fresh {
+			unchanged++
+			r.fresh = r.baseline == nil || *status != *r.baseline ||
+				status.CurIndex < status.TotalCount || unchanged >= sendStatusIdlePolls
+		}
+		if r.fresh && r.update(status) == 0 {
+			return r.Recipients(), nil
+		}
+		if status.TotalCount == 0 {
+			idle++
+		}
+		if idle >= sendStatusIdlePolls || time.Now().After(deadline) {
+			r.giveUp()
+			return r.Recipients(), nil
+		}
+
+		if err := sleep(ctx, sendStatusInterval); err != nil {
+			return r.Recipients(), err
+		}
+	}
+}
+
+// giveUp marks the recipients still pending as SendUnknown.
+func (r *SendResult) giveUp() {
+	for i := range r.recipients {
+		if r.recipients[i].Outcome == SendPending {
+			r.recipients[i].Outcome = SendUnknown
+		}
+	}
+}
+
+// update records the outcomes reported in status and returns the number of
+// recipients still pending.
+func (r *SendResult) update(status *sendStatusResponse) int {
+	succeeded := splitPhones(status.SucPhone)
+	failed := splitPhones(status.FailPhone)
+
+	pending := 0
+	for i := range r.recipients {
+		rs := &r.recipients[i]
+		if rs.Outcome != SendPending {
+			continue
+		}
+		switch {
+		case containsPhone(succeeded, rs.Phone):
+			rs.Outcome = SendSucceeded
+		case containsPhone(failed, rs.Phone):
+			rs.Outcome = SendFailed
+		default:
+			pending++
+		}
+	}
+	return pending
+}
+
+// splitPhones splits a list of phone numbers separated by ';'.
+func splitPhones(list string) []string {
+	var phones []string
+	for _, phone := range strings.Split(list, ";") {
+		if phone = strings.TrimSpace(phone); phone != "" {
+			phones = append(phones, phone)
+		}
+	}
+	return phones
+}
+
+// containsPhone reports whether phones contains phone. Numbers match if
+// their digits are equal or one ends with the other, as the device may
+// report a number in international format that was given in national
+// format, or the other way round.
+func containsPhone(phones []string, phone string) bool {
+	want := digits(phone)
+	for _, p := range phones {
+		got := digits(p)
+		if got == want {
+			return true
+		}
+		short, long := got, want
+		if len(short) > len(long) {
+			short, long = long, short
+		}
+		// Drop the trunk prefix of national numbers, e.g. 0912... for +98912...
+		short = strings.TrimLeft(short, "0")
+		if len(short) >= 7 && strings.HasSuffix(long, short) {
+			return true
+		}
+	}
+	return false
+}
+
+// digits returns the decimal digits of s.
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// MaxRecipients is the number of recipients the firmware accepts in a
+// single send request.
+const MaxRecipients = 20
+
+// SendSMSMulti sends msg to every phone number in phones. Recipients are
+// sent in batches of up to MaxRecipients per request, and each batch is
+// awaited before the next one is sent, since the device only reports the
+// status of the most recent send.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the requests.
+//   - msg: The message content to be sent.
+//   - phones: The recipients' phone numbers.
+//
+// Returns:
+//   - []RecipientStatus: The outcome for each recipient in order, for the
+//     batches sent so far if an error occurs.
+//   - error: An error if the device does not accept a batch or polling its
+//     status fails.
+func (h *Huawei) SendSMSMulti(ctx context.Context, msg string, phones []string) ([]RecipientStatus, error) {
+	statuses := make([]RecipientStatus, 0, len(phones))
+	for batch := range slices.Chunk(phones, MaxRecipients) {
+		result, err := h.sendSMS(ctx, h.newSMSRequest(-1, msg, batch))
+		if err != nil {
+			return statuses, err
+		}
+
+		batchStatuses, err := result.Wait(ctx)
+		statuses = append(statuses, batchStatuses...)
+		if err != nil {
+			return statuses, err
+		}
+	}
+	return statuses, nil
+}
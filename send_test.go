@@ -0,0 +1,112 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptSendStatus makes d answer send-status polls with previous until a
+// message is sent, then with the next entry of statuses on every poll,
+// repeating the last one.
+func scriptSendStatus(d *fakeDevice, previous string, statuses ...string) {
+	var mu sync.Mutex
+	polls := 0
+	d.handle("/api/sms/send-status", func(w http.ResponseWriter, r *http.Request) {
+		if d.count("/api/sms/send-sms") == 0 {
+			fmt.Fprint(w, previous)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(w, statuses[min(polls, len(statuses)-1)])
+		polls++
+	})
+}
+
+func sendStatus(total, cur int, sucPhone, failPhone string) string {
+	return fmt.Sprintf(`<response><Phone></Phone><SucPhone>%s</SucPhone><FailPhone>%s</FailPhone><TotalCount>%d</TotalCount><CurIndex>%d</CurIndex></response>`,
+		sucPhone, failPhone, total, cur)
+}
+
+func shortenSendStatus(t *testing.T) {
+	interval, timeout, perRecipient := sendStatusInterval, sendStatusTimeout, sendStatusPerRecipient
+	sendStatusInterval, sendStatusTimeout, sendStatusPerRecipient = time.Millisecond, 50*time.Millisecond, 0
+	t.Cleanup(func() {
+		sendStatusInterval, sendStatusTimeout, sendStatusPerRecipient = interval, timeout, perRecipient
+	})
+}
+
+func TestWaitIgnoresPreviousSend(t *testing.T) {
+	shortenSendStatus(t)
+	d := newFakeDevice(t)
+	scriptSendStatus(d, sendStatus(1, 1, "0912345678", ""),
+		sendStatus(1, 1, "0912345678", ""), // not started yet
+		sendStatus(1, 0, "", ""),
+		sendStatus(1, 1, "", "0912345678"),
+	)
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := h.SendSMS(context.Background(), "hi", "0912345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := result.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Outcome != SendFailed {
+		t.Errorf("outcome = %v, want %v", statuses[0].Outcome, SendFailed)
+	}
+}
+
+func TestWaitGivesUp(t *testing.T) {
+	shortenSendStatus(t)
+	d := newFakeDevice(t)
+	scriptSendStatus(d, "", sendStatus(2, 1, "0911111111", ""))
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := h.SendSMSMulti(context.Background(), "hi", []string{"0911111111", "0922222222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecipientStatus{{"0911111111", SendSucceeded}, {"0922222222", SendUnknown}}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+}
+
+func TestWaitSameRecipientTwice(t *testing.T) {
+	shortenSendStatus(t)
+	sendStatusTimeout = time.Minute
+	d := newFakeDevice(t)
+	// The device ends in the same status as after the previous send.
+	scriptSendStatus(d, sendStatus(1, 1, "0912345678", ""), sendStatus(1, 1, "0912345678", ""))
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := h.SendSMS(ctx, "hi", "0912345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := result.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Outcome != SendSucceeded {
+		t.Errorf("outcome = %v, want %v", statuses[0].Outcome, SendSucceeded)
+	}
+}
@@ -0,0 +1,178 @@
+package huawei
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// sessionCookie is the name of the cookie the device uses to track a session.
+const sessionCookie = "SessionID"
+
+// errNoSesTokInfo is returned by GetSesTokInfo when the firmware does not
+// provide /api/webserver/SesTokInfo.
+var errNoSesTokInfo = errors.New("session token info not supported")
+
+type sesTokInfoResponse struct {
+	XMLName xml.Name `xml:"response"`
+	SesInfo string   `xml:"SesInfo"`
+	TokInfo string   `xml:"TokInfo"`
+}
+
+// GetSesTokInfo seeds a new session from the /api/webserver/SesTokInfo endpoint.
+// The SessionID it returns is stored in the client's cookie jar and the token
+// replaces any tokens the client has queued, so that subsequent requests are
+// made within that session.
+// Returns an error if the request fails or the device does not provide the endpoint.
+func (h *Huawei) GetSesTokInfo(ctx context.Context) error {
+	if err := h.queue.acquire(ctx); err != nil {
+		return err
+	}
+	defer h.queue.release()
+	return h.getSesTokInfo(ctx)
+}
+
+// getSesTokInfo is GetSesTokInfo for callers already holding the device queue.
+func (h *Huawei) getSesTokInfo(ctx context.Context) error {
+	sesTokResp, err := doLocked[sesTokInfoResponse](ctx, h, "GET", "/api/webserver/SesTokInfo", "", nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errNoSesTokInfo
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := h.setSessionID(sesTokResp.SesInfo); err != nil {
+		return err
+	}
+	h.tokens = []string{sesTokResp.TokInfo}
+	return nil
+}
+
+// ensureSession seeds a session unless the cookie jar already holds one.
+// The caller must hold the device queue, as for nextToken and storeTokens.
+// Firmware without SesTokInfo hands out the cookie on its own, so that case
+// is not an error.
+func (h *Huawei) ensureSession(ctx context.Context) error {
+	if h.hasSession() {
+		return nil
+	}
+	if err := h.getSesTokInfo(ctx); err != nil && !errors.Is(err, errNoSesTokInfo) {
+		return err
+	}
+	return nil
+}
+
+// renewSession forgets the SessionID cookie and every queued token, which
+// the device no longer accepts once it dropped the session, and seeds a
+// new session. The caller must hold the device queue.
+func (h *Huawei) renewSession(ctx context.Context) error {
+	if err := h.clearSessionID(); err != nil {
+		return err
+	}
+	h.tokens, h.lastToken = nil, ""
+	return h.ensureSession(ctx)
+}
+
+// hasSession reports whether the cookie jar holds a SessionID for the device.
+func (h *Huawei) hasSession() bool {
+	u, err := url.Parse(h.IP)
+	if err != nil || h.client.Jar == nil {
+		return false
+	}
+	for _, c := range h.client.Jar.Cookies(u) {
+		if c.Name == sessionCookie && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// setSessionID stores the session from a SesInfo value, which the device
+// formats as "SessionID=<value>", in the cookie jar.
+func (h *Huawei) setSessionID(sesInfo string) error {
+	u, err := url.Parse(h.IP)
+	if err != nil {
+		return err
+	}
+	if h.client.Jar == nil {
+		return nil
+	}
+
+	value := strings.TrimSpace(sesInfo)
+	if name, v, ok := strings.Cut(value, "="); ok && name == sessionCookie {
+		value = v
+	}
+	h.client.Jar.SetCookies(u, []*http.Cookie{{Name: sessionCookie, Value: value, Path: "/"}})
+	return nil
+}
+
+// clearSessionID removes the SessionID cookie from the cookie jar.
+func (h *Huawei) clearSessionID() error {
+	u, err := url.Parse(h.IP)
+	if err != nil {
+		return err
+	}
+	if h.client.Jar == nil {
+		return nil
+	}
+	h.client.Jar.SetCookies(u, []*http.Cookie{{Name: sessionCookie, Path: "/", MaxAge: -1}})
+	return nil
+}
+
+// nextToken returns the verification token to send with the next request.
+// Tokens handed out by the device in response headers are used in order;
+// once the queue is exhausted a fresh token is fetched from
+// /api/webserver/token, or from SesTokInfo on firmware without it. Only
+// firmware providing neither reuses the last token.
+func (h *Huawei) nextToken(ctx context.Context) (string, error) {
+	if len(h.tokens) == 0 {
+		err := h.getToken(ctx)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			err = h.getSesTokInfo(ctx)
+		}
+		if errors.Is(err, errNoSesTokInfo) && h.lastToken != "" {
+			return h.lastToken, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	h.lastToken, h.tokens = h.tokens[0], h.tokens[1:]
+	return h.lastToken, nil
+}
+
+// storeTokens queues the verification tokens found in response headers.
+// Responses to most requests carry __RequestVerificationTokenone (and
+// optionally __RequestVerificationTokentwo) or __RequestVerificationToken.
+// A login response carries several tokens separated by '#', which replace
+// everything queued before since the device invalidates them on login.
+// Tokens already queued or just used are skipped, as some firmware sends
+// the same token in more than one header.
+func (h *Huawei) storeTokens(header http.Header) {
+	for _, key := range []string{
+		"__RequestVerificationTokenone",
+		"__RequestVerificationTokentwo",
+		"__RequestVerificationToken",
+	} {
+		value := header.Get(key)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "#") {
+			h.tokens, h.lastToken = nil, ""
+		}
+		for _, token := range strings.Split(value, "#") {
+			if token != "" && token != h.lastToken && !slices.Contains(h.tokens, token) {
+				h.tokens = append(h.tokens, token)
+			}
+		}
+	}
+}
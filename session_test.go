@@ -0,0 +1,76 @@
+package huawei
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestOneTimeTokens(t *testing.T) {
+	d := newFakeDevice(t)
+	d.noSesTokInfo = true
+	d.noHeaderTokens = true
+	d.oneTimeTokens = true
+
+	h, err := NewHuawei(d.URL(), WithCredentials("admin", "admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for range 3 {
+		if err := h.Connect(ctx); err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+	}
+	if n := d.count("/api/webserver/token"); n != 3 {
+		t.Errorf("fetched %d tokens for 3 requests, want 3", n)
+	}
+
+	d.mu.Lock()
+	d.rejectTokens = 1
+	d.mu.Unlock()
+	if err := h.Connect(ctx); err != nil {
+		t.Fatalf("Connect after a rejected token: %v", err)
+	}
+	if n := d.count("/api/user/login"); n != 0 {
+		t.Errorf("a rejected token caused %d logins", n)
+	}
+}
+
+func TestStoreTokensSkipsDuplicates(t *testing.T) {
+	h := &Huawei{}
+	header := http.Header{}
+	header.Set("__RequestVerificationTokenone", "a")
+	header.Set("__RequestVerificationToken", "a")
+	h.storeTokens(header)
+	if len(h.tokens) != 1 {
+		t.Errorf("queued tokens %q, want [a]", h.tokens)
+	}
+}
+
+func TestSessionRenewedAfterReboot(t *testing.T) {
+	d := newFakeDevice(t)
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := h.GetSmsCount(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	d.reboot()
+	if _, err := h.GetSmsCount(ctx); err != nil {
+		t.Errorf("GetSmsCount after a reboot: %v", err)
+	}
+	d.reboot()
+	if err := h.Connect(ctx); err != nil {
+		t.Errorf("Connect after a reboot: %v", err)
+	}
+	if n := d.count("/api/webserver/SesTokInfo"); n != 3 {
+		t.Errorf("requested %d sessions, want 3", n)
+	}
+	if n := d.count("/api/user/login"); n != 0 {
+		t.Errorf("a reboot caused %d logins", n)
+	}
+}
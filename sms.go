@@ -0,0 +1,385 @@
+package huawei
+
+import (
+	"context"
+	"encoding/xml"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smsDateLayout is the format of message dates, which the device reports in
+// its own local time without a zone.
+const smsDateLayout = "2006-01-02 15:04:05"
+
+// RawSMS is a message as reported by the device, with every field as text.
+type RawSMS struct {
+	Smstat   string `xml:"Smstat"`
+	Index    string `xml:"Index"`
+	Phone    string `xml:"Phone"`
+	Content  string `xml:"Content"`
+	Date     string `xml:"Date"`
+	Sca      string `xml:"Sca"`
+	SaveType string `xml:"SaveType"`
+	Priority string `xml:"Priority"`
+	SmsType  string `xml:"SmsType"`
+}
+
+// SMS is a message stored on the device.
+type SMS struct {
+	// Index identifies the message on the device, e.g. for DeleteSMS.
+	Index   int
+	Status  SMSStatus
+	Type    SMSType
+	Phone   string
+	Content string
+	// Date is the time the message was received or saved, in the location
+	// set with WithLocation. It is zero if the device reported no valid date.
+	Date time.Time
+	// Sca is the number of the service center that delivered the message.
+	Sca string
+
+	// Raw holds the fields as reported by the device.
+	Raw RawSMS
+}
+
+// SMSStatus is the state of a stored message.
+type SMSStatus int
+
+const (
+	SMSUnread SMSStatus = 0
+	SMSRead   SMSStatus = 1
+	SMSDraft  SMSStatus = 2
+	SMSSent   SMSStatus = 3
+	SMSFailed SMSStatus = 4
+)
+
+func (s SMSStatus) String() string {
+	switch s {
+	case SMSUnread:
+		return "unread"
+	case SMSRead:
+		return "read"
+	case SMSDraft:
+		return "draft"
+	case SMSSent:
+		return "sent"
+	case SMSFailed:
+		return "failed"
+	}
+	return "unknown SMS status " + strconv.Itoa(int(s))
+}
+
+// SMSType is the kind of a stored message.
+type SMSType int
+
+const (
+	SMSTypeNormal               SMSType = 1
+	SMSTypeConcatenated         SMSType = 2
+	SMSTypeDeliveryReport       SMSType = 7
+	SMSTypeDeliveryReportFailed SMSType = 8
+)
+
+func (t SMSType) String() string {
+	switch t {
+	case SMSTypeNormal:
+		return "normal"
+	case SMSTypeConcatenated:
+		return "concatenated"
+	case SMSTypeDeliveryReport:
+		return "delivery report"
+	case SMSTypeDeliveryReportFailed:
+		return "failed delivery report"
+	}
+	return "unknown SMS type " + strconv.Itoa(int(t))
+}
+
+// IsDeliveryReport reports whether the message is a delivery report for a
+// message sent earlier rather than a message from another party.
+func (t SMSType) IsDeliveryReport() bool {
+	return t == SMSTypeDeliveryReport || t == SMSTypeDeliveryReportFailed
+}
+
+// Recipients returns the phone numbers of the message. Drafts and sent
+// messages addressed to several numbers list them separated by ';'.
+func (m *SMS) Recipients() []string {
+	return splitPhones(m.Phone)
+}
+
+// decode converts the raw fields into an SMS, reading the date in loc.
+func (r *RawSMS) decode(loc *time.Location) SMS {
+	date, err := time.ParseInLocation(smsDateLayout, strings.TrimSpace(r.Date), loc)
+	if err != nil {
+		date = time.Time{}
+	}
+	return SMS{
+		Index:   atoi(r.Index),
+		Status:  SMSStatus(atoi(r.Smstat)),
+		Type:    SMSType(atoi(r.SmsType)),
+		Phone:   r.Phone,
+		Content: r.Content,
+		Date:    date,
+		Sca:     r.Sca,
+		Raw:     *r,
+	}
+}
+
+// decodeSMS converts raw messages into SMS values in the device's location.
+func (h *Huawei) decodeSMS(raw []RawSMS) []SMS {
+	messages := make([]SMS, len(raw))
+	for i := range raw {
+		messages[i] = raw[i].decode(h.location)
+	}
+	return messages
+}
+
+// Box is a message box on the device or on the SIM card.
+type Box int
+
+const (
+	Inbox     Box = 1
+	Outbox    Box = 2
+	Draft     Box = 3
+	Trash     Box = 4
+	SimInbox  Box = 5
+	SimOutbox Box = 6
+	SimDraft  Box = 7
+)
+
+func (b Box) String() string {
+	switch b {
+	case Inbox:
+		return "inbox"
+	case Outbox:
+		return "outbox"
+	case Draft:
+		return "draft"
+	case Trash:
+		return "trash"
+	case SimInbox:
+		return "SIM inbox"
+	case SimOutbox:
+		return "SIM outbox"
+	case SimDraft:
+		return "SIM draft"
+	}
+	return "unknown box " + strconv.Itoa(int(b))
+}
+
+// DefaultPageSize is the page size ListSMS uses when none is given. It is
+// the page size of the web interface.
+const DefaultPageSize = 20
+
+// MaxPageSize is the largest page most firmware returns; larger page sizes
+// are reduced to it.
+const MaxPageSize = 50
+
+// ListOptions selects the messages returned by ListSMS and AllSMS.
+type ListOptions struct {
+	// Box is the box to list, Inbox if zero.
+	Box Box
+	// Page is the 1-based page to fetch, the first if zero.
+	Page int
+	// PageSize is the number of messages per page, DefaultPageSize if zero
+	// and at most MaxPageSize.
+	PageSize int
+	// Ascending lists the oldest messages first instead of the newest.
+	Ascending bool
+	// UnreadFirst lists unread messages before read ones.
+	UnreadFirst bool
+}
+
+// request builds the sms-list request for the page selected by o.
+func (o ListOptions) request() *smsListRequest {
+	req := &smsListRequest{
+		PageIndex: max(o.Page, 1),
+		ReadCount: o.PageSize,
+		BoxType:   int(o.Box),
+	}
+	if req.ReadCount <= 0 {
+		req.ReadCount = DefaultPageSize
+	}
+	req.ReadCount = min(req.ReadCount, MaxPageSize)
+	if req.BoxType == 0 {
+		req.BoxType = int(Inbox)
+	}
+	if o.Ascending {
+		req.Ascending = 1
+	}
+	if o.UnreadFirst {
+		req.UnreadPreferred = 1
+	}
+	return req
+}
+
+// ListSMS retrieves one page of messages from a box.
+// It sends a POST request to the /api/sms/sms-list endpoint.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - opts: The box, page and order of the messages to list.
+//
+// Returns:
+//   - []SMS: The messages on the requested page.
+//   - int: The total number of messages in the box.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (h *Huawei) ListSMS(ctx context.Context, opts ListOptions) ([]SMS, int, error) {
+	resp, err := do[SMSListResponse](ctx, h, "POST", "/api/sms/sms-list", opts.request())
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.decodeSMS(resp.Messages), resp.Count, nil
+}
+
+// AllSMS returns an iterator over every message of a box, starting at the
+// page selected by opts and fetching further pages as the loop advances.
+// Iteration stops once as many messages as the box holds have been listed
+// or a page comes back empty, or after yielding the first error.
+//
+// Messages deleted or received while iterating shift the pages, so a
+// message may be skipped or seen twice; collect the messages first when
+// deleting them.
+func (h *Huawei) AllSMS(ctx context.Context, opts ListOptions) iter.Seq2[SMS, error] {
+	return func(yield func(SMS, error) bool) {
+		req := opts.request()
+		seen := (req.PageIndex - 1) * req.ReadCount
+		for {
+			resp, err := do[SMSListResponse](ctx, h, "POST", "/api/sms/sms-list", req)
+			if err != nil {
+				yield(SMS{}, err)
+				return
+			}
+			for _, msg := range h.decodeSMS(resp.Messages) {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+
+			seen += len(resp.Messages)
+			if len(resp.Messages) == 0 || seen >= resp.Count {
+				return
+			}
+			req.PageIndex++
+		}
+	}
+}
+
+type setReadRequest struct {
+	XMLName xml.Name `xml:"request"`
+	Index   []int    `xml:"Index"`
+}
+
+// Indexes returns the indexes of messages, for MarkRead and DeleteSMS.
+func Indexes(messages []SMS) []int {
+	indexes := make([]int, len(messages))
+	for i, msg := range messages {
+		indexes[i] = msg.Index
+	}
+	return indexes
+}
+
+// MarkReadBatchSize is the number of messages MarkRead marks per request.
+const MarkReadBatchSize = 50
+
+// MarkRead marks messages as read. It sends POST requests to the
+// /api/sms/set-read endpoint, each carrying up to MarkReadBatchSize
+// indexes; calling it without indexes does nothing.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the request.
+//   - indexes: The indexes of the messages to mark, see Indexes.
+//
+// Returns:
+//   - error: An error if a request fails or the device rejects it. Batches
+//     sent before the failing one stay marked.
+func (h *Huawei) MarkRead(ctx context.Context, indexes ...int) error {
+	_, err := h.markRead(ctx, indexes)
+	return err
+}
+
+// markRead is MarkRead, also returning the number of messages marked
+// before a batch failed.
+func (h *Huawei) markRead(ctx context.Context, indexes []int) (int, error) {
+	marked := 0
+	for batch := range slices.Chunk(indexes, MarkReadBatchSize) {
+		if _, err := do[Response](ctx, h, "POST", "/api/sms/set-read", &setReadRequest{Index: batch}); err != nil {
+			return marked, err
+		}
+		marked += len(batch)
+	}
+	return marked, nil
+}
+
+// MarkAllRead marks every unread message in the local and SIM inboxes as
+// read, which also clears the unread indicator of the device. Each inbox is
+// listed until as many unread messages were found as the device counts, or
+// to its end, since not all firmware lists unread messages first.
+//
+// Returns:
+//   - int: The number of messages marked as read, also when marking fails
+//     partway.
+//   - error: An error if listing or marking the messages fails.
+func (h *Huawei) MarkAllRead(ctx context.Context) (int, error) {
+	count, err := h.GetSmsCount(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var unread []int
+	for _, inbox := range []struct {
+		box    Box
+		unread int
+	}{{Inbox, count.LocalUnread}, {SimInbox, count.SimUnread}} {
+		if inbox.unread == 0 {
+			continue
+		}
+		found := 0
+		for msg, err := range h.AllSMS(ctx, ListOptions{Box: inbox.box, UnreadFirst: true}) {
+			if err != nil {
+				return 0, err
+			}
+			if msg.Status != SMSUnread {
+				continue
+			}
+			unread = append(unread, msg.Index)
+			if found++; found == inbox.unread {
+				break
+			}
+		}
+	}
+
+	return h.markRead(ctx, unread)
+}
+
+// DeleteBatchSize is the number of messages DeleteSMS deletes per request.
+const DeleteBatchSize = 50
+
+// PurgeBox deletes the messages of a box older than olderThan, or all of
+// them if olderThan is zero. Messages without a valid date are kept unless
+// the whole box is purged.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadline of the requests.
+//   - box: The box to purge.
+//   - olderThan: The minimum age of the messages to delete.
+//
+// Returns:
+//   - int: The number of messages deleted, also when deleting fails partway.
+//   - error: An error if listing or deleting the messages fails.
+func (h *Huawei) PurgeBox(ctx context.Context, box Box, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	var indexes []int
+	for msg, err := range h.AllSMS(ctx, ListOptions{Box: box, PageSize: MaxPageSize}) {
+		if err != nil {
+			return 0, err
+		}
+		if olderThan == 0 || (!msg.Date.IsZero() && msg.Date.Before(cutoff)) {
+			indexes = append(indexes, msg.Index)
+		}
+	}
+
+	return h.deleteSMS(ctx, indexes)
+}
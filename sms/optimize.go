@@ -0,0 +1,97 @@
+package sms
+
+import "strings"
+
+// lookalikes maps characters outside the GSM 03.38 alphabet to GSM 03.38
+// text that looks the same or close enough.
+var lookalikes = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '‹': "'", '›': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...", '•': "*", '×': "x", '\t': " ",
+	// No-break, figure, narrow and other fixed-width spaces.
+	'\u00a0': " ", '\u2000': " ", '\u2001': " ", '\u2002': " ", '\u2003': " ",
+	'\u2004': " ", '\u2005': " ", '\u2006': " ", '\u2007': " ", '\u2008': " ",
+	'\u2009': " ", '\u200a': " ", '\u202f': " ", '\u205f': " ", '\u3000': " ",
+	// Zero-width space, word joiner and byte order mark.
+	'\u200b': "", '\u2060': "", '\ufeff': "",
+}
+
+// UCS2Chars returns the distinct characters of text that are not part of
+// the GSM 03.38 alphabet and so force the message into UCS-2, in the order
+// they first appear.
+func UCS2Chars(text string) []rune {
+	var chars []rune
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if !IsGSM7(r) && !seen[r] {
+			seen[r] = true
+			chars = append(chars, r)
+		}
+	}
+	return chars
+}
+
+// Optimizer rewrites text to fit the GSM 03.38 alphabet, so that it is sent
+// in fewer segments. The zero value replaces typographic lookalikes such as
+// smart quotes, dashes and non-breaking spaces.
+type Optimizer struct {
+	// Digits also replaces Persian and Arabic-Indic digits with ASCII
+	// digits.
+	Digits bool
+}
+
+// Preview compares a message before and after optimization.
+type Preview struct {
+	// Text is the optimized text.
+	Text   string
+	Before Info
+	After  Info
+	// UCS2Chars lists the characters still forcing the optimized text into
+	// UCS-2, see UCS2Chars.
+	UCS2Chars []rune
+}
+
+// Optimize returns text with lookalikes replaced. Text that would still be
+// sent in UCS-2 after replacing them is returned unchanged, since rewriting
+// it would not save anything.
+func (o *Optimizer) Optimize(text string) string {
+	optimized := o.replace(text)
+	if Detect(optimized) != GSM7 {
+		return text
+	}
+	return optimized
+}
+
+// Preview optimizes text and reports the encoding and segments of the
+// message before and after.
+func (o *Optimizer) Preview(text string) Preview {
+	optimized := o.Optimize(text)
+	return Preview{
+		Text:      optimized,
+		Before:    Analyze(text),
+		After:     Analyze(optimized),
+		UCS2Chars: UCS2Chars(optimized),
+	}
+}
+
+// replace replaces every lookalike in text.
+func (o *Optimizer) replace(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case o.Digits && r >= '۰' && r <= '۹':
+			b.WriteRune('0' + r - '۰')
+		case o.Digits && r >= '٠' && r <= '٩':
+			b.WriteRune('0' + r - '٠')
+		default:
+			if s, ok := lookalikes[r]; ok {
+				b.WriteString(s)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptimizer(t *testing.T) {
+	tests := []struct {
+		name string
+		o    Optimizer
+		text string
+		want string
+	}{
+		{"smart quotes", Optimizer{}, "“Don’t”", `"Don't"`},
+		{"dashes and spaces", Optimizer{}, "a — b…", "a - b..."},
+		{"digits kept by default", Optimizer{}, "code ۱۲۳", "code ۱۲۳"},
+		{"digits", Optimizer{Digits: true}, "code ۱۲۳ ٤٥", "code 123 45"},
+		{"still UCS-2", Optimizer{}, "سلام “x”", "سلام “x”"},
+	}
+	for _, tt := range tests {
+		if got := tt.o.Optimize(tt.text); got != tt.want {
+			t.Errorf("%s: Optimize(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+
+	o := Optimizer{}
+	p := o.Preview(strings.Repeat("a", 100) + "’")
+	if p.Before.Segments != 2 || p.After.Segments != 1 || len(p.UCS2Chars) != 0 {
+		t.Errorf("Preview = %+v", p)
+	}
+}
@@ -0,0 +1,176 @@
+// Package sms computes how text is encoded and split into segments when it
+// is sent as an SMS, so the cost of a message is known before sending it.
+//
+// A message is sent in the GSM 03.38 7-bit alphabet if every character is
+// part of it, and in UCS-2 otherwise. A single segment holds 160 septets or
+// 70 UTF-16 code units; longer messages are split into segments of 153
+// septets or 67 code units, the rest being taken by the concatenation
+// header.
+package sms
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Encoding is the alphabet a message is sent in.
+type Encoding int
+
+const (
+	GSM7 Encoding = iota
+	UCS2
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case GSM7:
+		return "GSM-7"
+	case UCS2:
+		return "UCS-2"
+	}
+	return "unknown encoding " + strconv.Itoa(int(e))
+}
+
+// Segment limits in septets for GSM7 and in UTF-16 code units for UCS2.
+const (
+	GSM7SingleLimit = 160
+	GSM7MultiLimit  = 153
+	UCS2SingleLimit = 70
+	UCS2MultiLimit  = 67
+)
+
+// gsm7Basic is the GSM 03.38 basic character set, less the escape to the
+// extension table.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension is the GSM 03.38 extension table. Each of its characters
+// takes two septets, an escape followed by the character.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// IsGSM7 reports whether r is part of the GSM 03.38 alphabet, including the
+// extension table.
+func IsGSM7(r rune) bool {
+	return strings.ContainsRune(gsm7Basic, r) || strings.ContainsRune(gsm7Extension, r)
+}
+
+// Detect returns the encoding text is sent in.
+func Detect(text string) Encoding {
+	for _, r := range text {
+		if !IsGSM7(r) {
+			return UCS2
+		}
+	}
+	return GSM7
+}
+
+// Info describes how a message is sent.
+type Info struct {
+	Encoding Encoding
+	// Length is the size of the message in septets for GSM7 and in UTF-16
+	// code units for UCS2.
+	Length int
+	// Segments is the number of segments the message is sent in, zero for
+	// an empty message.
+	Segments int
+	// Remaining is the number of septets or code units left in the last
+	// segment.
+	Remaining int
+}
+
+// Analyze returns how text is encoded and split into segments.
+func Analyze(text string) Info {
+	enc := Detect(text)
+	parts, length := split(text, enc)
+	info := Info{Encoding: enc, Length: length, Segments: len(parts)}
+	if len(parts) > 0 {
+		limit := multiLimit(enc)
+		if len(parts) == 1 {
+			limit = singleLimit(enc)
+		}
+		info.Remaining = limit - unitsOf(parts[len(parts)-1], enc)
+	}
+	return info
+}
+
+// Segments returns the number of segments text is sent in.
+func Segments(text string) int {
+	return Analyze(text).Segments
+}
+
+// Split returns the text of each segment text is sent in. Characters of the
+// extension table and UTF-16 surrogate pairs are never split across
+// segments.
+func Split(text string) []string {
+	parts, _ := split(text, Detect(text))
+	return parts
+}
+
+// Length returns the length of text in UTF-16 code units, which is how the
+// device expects the length of a message to be reported.
+func Length(text string) int {
+	return unitsOf(text, UCS2)
+}
+
+// split splits text into segments for enc and returns them along with the
+// total length of text in septets or code units.
+func split(text string, enc Encoding) ([]string, int) {
+	length := unitsOf(text, enc)
+	if length == 0 {
+		return nil, 0
+	}
+	if length <= singleLimit(enc) {
+		return []string{text}, length
+	}
+
+	limit := multiLimit(enc)
+	var parts []string
+	start, used := 0, 0
+	for i, r := range text {
+		n := runeUnits(r, enc)
+		if used+n > limit {
+			parts = append(parts, text[start:i])
+			start, used = i, 0
+		}
+		used += n
+	}
+	return append(parts, text[start:]), length
+}
+
+// unitsOf returns the length of text in septets or code units for enc.
+func unitsOf(text string, enc Encoding) int {
+	n := 0
+	for _, r := range text {
+		n += runeUnits(r, enc)
+	}
+	return n
+}
+
+// runeUnits returns the septets or code units r takes in enc.
+func runeUnits(r rune, enc Encoding) int {
+	if enc == GSM7 {
+		if strings.ContainsRune(gsm7Extension, r) {
+			return 2
+		}
+		return 1
+	}
+	if utf16.RuneLen(r) == 2 {
+		return 2
+	}
+	return 1
+}
+
+func singleLimit(enc Encoding) int {
+	if enc == GSM7 {
+		return GSM7SingleLimit
+	}
+	return UCS2SingleLimit
+}
+
+func multiLimit(enc Encoding) int {
+	if enc == GSM7 {
+		return GSM7MultiLimit
+	}
+	return UCS2MultiLimit
+}
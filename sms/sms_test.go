@@ -0,0 +1,90 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding Encoding
+		length   int
+		parts    []int // length of each segment in septets or code units
+	}{
+		{"empty", "", GSM7, 0, nil},
+		{"160 septets", strings.Repeat("a", 160), GSM7, 160, []int{160}},
+		{"161 septets", strings.Repeat("a", 161), GSM7, 161, []int{153, 8}},
+		{"306 septets", strings.Repeat("a", 306), GSM7, 306, []int{153, 153}},
+		{"307 septets", strings.Repeat("a", 307), GSM7, 307, []int{153, 153, 1}},
+		{"extension counts twice", strings.Repeat("a", 159) + "€", GSM7, 161, []int{153, 8}},
+		{"extension at split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), GSM7, 164, []int{152, 12}},
+		{"extension fits split", strings.Repeat("a", 151) + "€" + strings.Repeat("a", 10), GSM7, 163, []int{153, 10}},
+		{"70 code units", strings.Repeat("س", 70), UCS2, 70, []int{70}},
+		{"71 code units", strings.Repeat("س", 71), UCS2, 71, []int{67, 4}},
+		{"surrogate pair counts twice", strings.Repeat("😀", 35), UCS2, 70, []int{70}},
+		{"surrogate pair at split", strings.Repeat("س", 66) + "😀" + "س", UCS2, 69, []int{69}},
+		{"surrogate pair across split", strings.Repeat("س", 66) + "😀" + strings.Repeat("س", 4), UCS2, 72, []int{66, 6}},
+		{"one character forces UCS-2", strings.Repeat("a", 100) + "’", UCS2, 101, []int{67, 34}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Analyze(tt.text)
+			if info.Encoding != tt.encoding || info.Length != tt.length || info.Segments != len(tt.parts) {
+				t.Errorf("Analyze = %+v, want encoding %v, length %d, %d segments", info, tt.encoding, tt.length, len(tt.parts))
+			}
+
+			parts := Split(tt.text)
+			if len(parts) != len(tt.parts) {
+				t.Fatalf("Split returned %d segments, want %d", len(parts), len(tt.parts))
+			}
+			for i, part := range parts {
+				if n := unitsOf(part, tt.encoding); n != tt.parts[i] {
+					t.Errorf("segment %d has length %d, want %d", i, n, tt.parts[i])
+				}
+			}
+			if strings.Join(parts, "") != tt.text {
+				t.Error("segments do not add up to the text")
+			}
+
+			if len(tt.parts) > 0 {
+				limit := UCS2MultiLimit
+				switch {
+				case tt.encoding == GSM7 && len(tt.parts) == 1:
+					limit = GSM7SingleLimit
+				case tt.encoding == GSM7:
+					limit = GSM7MultiLimit
+				case len(tt.parts) == 1:
+					limit = UCS2SingleLimit
+				}
+				if want := limit - tt.parts[len(tt.parts)-1]; info.Remaining != want {
+					t.Errorf("Remaining = %d, want %d", info.Remaining, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"سلام دنیا", 9},
+		{"یادآوری واکسن ۱۹:۳۰", 19},
+		{"به‌موقع", 7},
+		{"hi 😀", 5},
+	}
+	for _, tt := range tests {
+		if got := Length(tt.text); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+		if got := len(utf16.Encode([]rune(tt.text))); got != tt.want {
+			t.Errorf("UTF-16 length of %q = %d, test expects %d", tt.text, got, tt.want)
+		}
+	}
+}
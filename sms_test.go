@@ -0,0 +1,83 @@
+package huawei
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAllSMSWalksEveryPage(t *testing.T) {
+	d := newFakeDevice(t)
+	d.setMessages(fakeMessages(120)...)
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pageSize := range []int{0, 30, 100} {
+		seen := make(map[int]bool)
+		for msg, err := range h.AllSMS(context.Background(), ListOptions{Box: Outbox, PageSize: pageSize}) {
+			if err != nil {
+				t.Fatalf("PageSize %d: %v", pageSize, err)
+			}
+			seen[msg.Index] = true
+		}
+		if len(seen) != 120 {
+			t.Errorf("PageSize %d: listed %d messages, want 120", pageSize, len(seen))
+		}
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	d := newFakeDevice(t)
+	messages := fakeMessages(120)
+	// The fake lists messages in stored order, ignoring UnreadPreferred.
+	for _, i := range []int{10, 60, 119} {
+		messages[i].Unread = true
+	}
+	d.setMessages(messages...)
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := h.MarkAllRead(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("MarkAllRead = %d, want 3", n)
+	}
+	if unread := d.unread(); len(unread) != 0 {
+		t.Errorf("messages %v left unread", unread)
+	}
+}
+
+func TestMarkReadBatches(t *testing.T) {
+	d := newFakeDevice(t)
+	messages := fakeMessages(120)
+	for i := range messages {
+		messages[i].Unread = true
+	}
+	d.setMessages(messages...)
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.MarkRead(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	indexes := make([]int, len(messages))
+	for i, m := range messages {
+		indexes[i] = m.Index
+	}
+	if err := h.MarkRead(context.Background(), indexes...); err != nil {
+		t.Fatal(err)
+	}
+	if n := d.count("/api/sms/set-read"); n != 3 {
+		t.Errorf("sent %d set-read requests for 120 messages, want 3", n)
+	}
+	if unread := d.unread(); len(unread) != 0 {
+		t.Errorf("%d messages left unread", len(unread))
+	}
+}
@@ -0,0 +1,343 @@
+package huawei
+
+import (
+	"context"
+	"encoding/xml"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type ConnectionStatusResponse struct {
+	XMLName              xml.Name `xml:"response"`
+	ConnectionStatus     string   `xml:"ConnectionStatus"`
+	SignalStrength       string   `xml:"SignalStrength"`
+	SignalIcon           string   `xml:"SignalIcon"`
+	CurrentNetworkType   string   `xml:"CurrentNetworkType"`
+	CurrentNetworkTypeEx string   `xml:"CurrentNetworkTypeEx"`
+	CurrentServiceDomain string   `xml:"CurrentServiceDomain"`
+	RoamingStatus        string   `xml:"RoamingStatus"`
+	BatteryStatus        string   `xml:"BatteryStatus"`
+	BatteryLevel         string   `xml:"BatteryLevel"`
+	SimlockStatus        string   `xml:"SimlockStatus"`
+	WanIPAddress         string   `xml:"WanIPAddress"`
+	PrimaryDNS           string   `xml:"PrimaryDns"`
+	SecondaryDNS         string   `xml:"SecondaryDns"`
+	CurrentWifiUser      string   `xml:"CurrentWifiUser"`
+	TotalWifiUser        string   `xml:"TotalWifiUser"`
+	ServiceStatus        string   `xml:"ServiceStatus"`
+	SimStatus            string   `xml:"SimStatus"`
+	WifiStatus           string   `xml:"WifiStatus"`
+}
+
+// ConnectionStatus is the decoded connection status of the device.
+type ConnectionStatus struct {
+	State          ConnectionState
+	NetworkType    NetworkType
+	Roaming        bool
+	SIMStatus      SIMStatus
+	SIMLocked      bool
+	ServiceUp      bool
+	ServiceDomain  ServiceDomain
+	SignalStrength int // signal strength in percent
+	SignalBars     int // signal icon level, usually 0 to 5
+	BatteryStatus  BatteryStatus
+	BatteryLevel   int
+	WanIP          net.IP
+	PrimaryDNS     net.IP
+	SecondaryDNS   net.IP
+	WifiEnabled    bool
+	WifiUsers      int
+	WifiMaxUsers   int
+
+	// Raw holds the fields as reported by the device.
+	Raw ConnectionStatusResponse
+}
+
+// ConnectionState is the state of the mobile data connection.
+type ConnectionState int
+
+const (
+	StateConnecting    ConnectionState = 900
+	StateConnected     ConnectionState = 901
+	StateDisconnected  ConnectionState = 902
+	StateDisconnecting ConnectionState = 903
+
+	// Failure states.
+	StateConnectFailed           ConnectionState = 2
+	StateNetworkAccessNotAllowed ConnectionState = 7
+	StateRoamingNotAllowed       ConnectionState = 12
+	StateNoAutoconnect           ConnectionState = 112
+	StateNoAutoconnectRoaming    ConnectionState = 113
+	StateNoReconnect             ConnectionState = 114
+	StateNoReconnectRoaming      ConnectionState = 115
+	StateBandwidthExceeded       ConnectionState = 201
+)
+
+// Failed reports whether the state is one of the error states the device
+// reports when it could not or may not connect. Unknown states, including
+// the zero an empty field decodes to, are not failures.
+func (s ConnectionState) Failed() bool {
+	switch s {
+	case StateConnectFailed, 3, 5, 8, 20, 21, 23, 27, 28, 29, 30, 31, 32, 33,
+		StateNetworkAccessNotAllowed, 11, 14, 37,
+		StateRoamingNotAllowed, 13,
+		StateNoAutoconnect, StateNoAutoconnectRoaming, StateNoReconnect, StateNoReconnectRoaming,
+		StateBandwidthExceeded:
+		return true
+	}
+	return false
+}
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateDisconnecting:
+		return "disconnecting"
+	case StateConnectFailed, 3, 5, 8, 20, 21, 23, 27, 28, 29, 30, 31, 32, 33:
+		return "connection failed"
+	case StateNetworkAccessNotAllowed, 11, 14, 37:
+		return "network access not allowed"
+	case StateRoamingNotAllowed, 13:
+		return "roaming not allowed"
+	case StateNoAutoconnect:
+		return "no autoconnect"
+	case StateNoAutoconnectRoaming:
+		return "no autoconnect while roaming"
+	case StateNoReconnect:
+		return "no reconnect"
+	case StateNoReconnectRoaming:
+		return "no reconnect while roaming"
+	case StateBandwidthExceeded:
+		return "bandwidth exceeded"
+	}
+	return "unknown state " + strconv.Itoa(int(s))
+}
+
+// NetworkType is the radio access technology the device is registered on.
+type NetworkType int
+
+const (
+	NetworkNone NetworkType = iota
+	NetworkGSM
+	NetworkGPRS
+	NetworkEDGE
+	NetworkCDMA
+	NetworkWCDMA
+	NetworkHSDPA
+	NetworkHSUPA
+	NetworkHSPA
+	NetworkHSPAPlus
+	NetworkDCHSPAPlus
+	NetworkTDSCDMA
+	NetworkLTE
+	NetworkLTECA
+	NetworkNR
+	NetworkUnknown
+)
+
+var networkTypeNames = [...]string{
+	NetworkNone:       "no service",
+	NetworkGSM:        "GSM",
+	NetworkGPRS:       "GPRS",
+	NetworkEDGE:       "EDGE",
+	NetworkCDMA:       "CDMA",
+	NetworkWCDMA:      "WCDMA",
+	NetworkHSDPA:      "HSDPA",
+	NetworkHSUPA:      "HSUPA",
+	NetworkHSPA:       "HSPA",
+	NetworkHSPAPlus:   "HSPA+",
+	NetworkDCHSPAPlus: "DC-HSPA+",
+	NetworkTDSCDMA:    "TD-SCDMA",
+	NetworkLTE:        "LTE",
+	NetworkLTECA:      "LTE-CA",
+	NetworkNR:         "NR",
+	NetworkUnknown:    "unknown",
+}
+
+func (t NetworkType) String() string {
+	if t < 0 || int(t) >= len(networkTypeNames) {
+		return "unknown network type " + strconv.Itoa(int(t))
+	}
+	return networkTypeNames[t]
+}
+
+// networkTypeCodes maps the codes of CurrentNetworkType and
+// CurrentNetworkTypeEx to network types. The two fields use distinct codes
+// for everything beyond GSM, GPRS and EDGE, so one table covers both.
+var networkTypeCodes = map[int]NetworkType{
+	0: NetworkNone, 1: NetworkGSM, 2: NetworkGPRS, 3: NetworkEDGE,
+
+	// CurrentNetworkType
+	4: NetworkWCDMA, 5: NetworkHSDPA, 6: NetworkHSUPA, 7: NetworkHSPA,
+	8: NetworkTDSCDMA, 9: NetworkHSPAPlus, 17: NetworkHSPAPlus, 18: NetworkHSPAPlus,
+	19: NetworkLTE,
+	10: NetworkCDMA, 11: NetworkCDMA, 12: NetworkCDMA, 13: NetworkCDMA,
+	14: NetworkCDMA, 15: NetworkCDMA, 16: NetworkCDMA,
+
+	// CurrentNetworkTypeEx
+	21: NetworkCDMA, 22: NetworkCDMA, 23: NetworkCDMA, 24: NetworkCDMA,
+	25: NetworkCDMA, 26: NetworkCDMA, 27: NetworkCDMA,
+	41: NetworkWCDMA, 42: NetworkHSDPA, 43: NetworkHSUPA, 44: NetworkHSPA,
+	45: NetworkHSPAPlus, 46: NetworkDCHSPAPlus,
+	61: NetworkTDSCDMA, 62: NetworkTDSCDMA, 63: NetworkTDSCDMA, 64: NetworkTDSCDMA, 65: NetworkTDSCDMA,
+	101: NetworkLTE, 1011: NetworkLTECA, 111: NetworkNR,
+}
+
+// parseNetworkType decodes the network type, preferring the more precise
+// CurrentNetworkTypeEx when the firmware reports it.
+func parseNetworkType(typ, typEx string) NetworkType {
+	code := typ
+	if strings.TrimSpace(typEx) != "" {
+		code = typEx
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil {
+		return NetworkUnknown
+	}
+	if t, ok := networkTypeCodes[n]; ok {
+		return t
+	}
+	return NetworkUnknown
+}
+
+// SIMStatus is the state of the SIM card.
+type SIMStatus int
+
+const (
+	SIMInvalid        SIMStatus = 0
+	SIMValid          SIMStatus = 1
+	SIMInvalidForCS   SIMStatus = 2
+	SIMInvalidForPS   SIMStatus = 3
+	SIMInvalidForCSPS SIMStatus = 4
+	SIMROMSim         SIMStatus = 240
+	SIMNotPresent     SIMStatus = 255
+)
+
+func (s SIMStatus) String() string {
+	switch s {
+	case SIMInvalid:
+		return "invalid"
+	case SIMValid:
+		return "valid"
+	case SIMInvalidForCS:
+		return "invalid for circuit switched services"
+	case SIMInvalidForPS:
+		return "invalid for packet switched services"
+	case SIMInvalidForCSPS:
+		return "invalid for circuit and packet switched services"
+	case SIMROMSim:
+		return "ROM SIM"
+	case SIMNotPresent:
+		return "not present"
+	}
+	return "unknown SIM status " + strconv.Itoa(int(s))
+}
+
+// ServiceDomain is the service domain the device is registered for.
+type ServiceDomain int
+
+const (
+	DomainNone ServiceDomain = 0
+	DomainCS   ServiceDomain = 1
+	DomainPS   ServiceDomain = 2
+	DomainCSPS ServiceDomain = 3
+)
+
+func (d ServiceDomain) String() string {
+	switch d {
+	case DomainNone:
+		return "no service"
+	case DomainCS:
+		return "CS"
+	case DomainPS:
+		return "PS"
+	case DomainCSPS:
+		return "CS+PS"
+	}
+	return "unknown service domain " + strconv.Itoa(int(d))
+}
+
+// BatteryStatus is the charging state of battery powered devices.
+type BatteryStatus int
+
+const (
+	BatteryLow      BatteryStatus = -1
+	BatteryNormal   BatteryStatus = 0
+	BatteryCharging BatteryStatus = 1
+)
+
+func (b BatteryStatus) String() string {
+	switch b {
+	case BatteryLow:
+		return "low"
+	case BatteryNormal:
+		return "normal"
+	case BatteryCharging:
+		return "charging"
+	}
+	return "unknown battery status " + strconv.Itoa(int(b))
+}
+
+// GetConnectionStatus retrieves the current connection status from the Huawei device.
+// It sends a GET request to the /api/monitoring/status endpoint and decodes the
+// XML response into a ConnectionStatus. Fields the device leaves empty are
+// left at their zero value.
+//
+// Returns:
+//   - *ConnectionStatus: The decoded connection status.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (h *Huawei) GetConnectionStatus(ctx context.Context) (*ConnectionStatus, error) {
+	resp, err := do[ConnectionStatusResponse](ctx, h, "GET", "/api/monitoring/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.decode(), nil
+}
+
+// IsConnected checks the connection status of the Huawei device.
+// It returns true if the device is connected, otherwise false.
+// If there is an error retrieving the connection status, it returns false along with the error.
+func (h *Huawei) IsConnected(ctx context.Context) (bool, error) {
+	status, err := h.GetConnectionStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+	return status.State == StateConnected, nil
+}
+
+// decode converts the raw fields into a ConnectionStatus.
+func (r *ConnectionStatusResponse) decode() *ConnectionStatus {
+	return &ConnectionStatus{
+		State:          ConnectionState(atoi(r.ConnectionStatus)),
+		NetworkType:    parseNetworkType(r.CurrentNetworkType, r.CurrentNetworkTypeEx),
+		Roaming:        atoi(r.RoamingStatus) == 1,
+		SIMStatus:      SIMStatus(atoi(r.SimStatus)),
+		SIMLocked:      atoi(r.SimlockStatus) == 1,
+		ServiceUp:      atoi(r.ServiceStatus) == 2,
+		ServiceDomain:  ServiceDomain(atoi(r.CurrentServiceDomain)),
+		SignalStrength: atoi(r.SignalStrength),
+		SignalBars:     atoi(r.SignalIcon),
+		BatteryStatus:  BatteryStatus(atoi(r.BatteryStatus)),
+		BatteryLevel:   atoi(r.BatteryLevel),
+		WanIP:          net.ParseIP(strings.TrimSpace(r.WanIPAddress)),
+		PrimaryDNS:     net.ParseIP(strings.TrimSpace(r.PrimaryDNS)),
+		SecondaryDNS:   net.ParseIP(strings.TrimSpace(r.SecondaryDNS)),
+		WifiEnabled:    atoi(r.WifiStatus) == 1,
+		WifiUsers:      atoi(r.CurrentWifiUser),
+		WifiMaxUsers:   atoi(r.TotalWifiUser),
+		Raw:            *r,
+	}
+}
+
+// atoi parses a numeric field reported by the device, treating empty or
+// malformed values as zero.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
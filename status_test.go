@@ -0,0 +1,61 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetConnectionStatus(t *testing.T) {
+	d := newFakeDevice(t)
+	var status string
+	d.handle("/api/monitoring/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, status)
+	})
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	status = `<response><ConnectionStatus>901</ConnectionStatus><SignalIcon>4</SignalIcon>` +
+		`<CurrentNetworkType>19</CurrentNetworkType><CurrentNetworkTypeEx>1011</CurrentNetworkTypeEx>` +
+		`<WanIPAddress>10.64.12.7</WanIPAddress><PrimaryDns> 8.8.8.8 </PrimaryDns><SecondaryDns></SecondaryDns>` +
+		`<SimStatus>1</SimStatus></response>`
+	s, err := h.GetConnectionStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != StateConnected || s.State.Failed() {
+		t.Errorf("State = %v, failed %v", s.State, s.State.Failed())
+	}
+	if s.NetworkType != NetworkLTECA {
+		t.Errorf("NetworkType = %v, want %v", s.NetworkType, NetworkLTECA)
+	}
+	if s.SignalBars != 4 || s.SIMStatus != SIMValid {
+		t.Errorf("SignalBars = %d, SIMStatus = %v", s.SignalBars, s.SIMStatus)
+	}
+	if s.WanIP.String() != "10.64.12.7" || s.PrimaryDNS.String() != "8.8.8.8" || s.SecondaryDNS != nil {
+		t.Errorf("WanIP = %v, PrimaryDNS = %v, SecondaryDNS = %v", s.WanIP, s.PrimaryDNS, s.SecondaryDNS)
+	}
+
+	status = `<response><ConnectionStatus>112</ConnectionStatus><CurrentNetworkType>19</CurrentNetworkType><CurrentNetworkTypeEx></CurrentNetworkTypeEx></response>`
+	if s, err = h.GetConnectionStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.NetworkType != NetworkLTE {
+		t.Errorf("NetworkType without CurrentNetworkTypeEx = %v, want %v", s.NetworkType, NetworkLTE)
+	}
+	if !s.State.Failed() {
+		t.Errorf("state %v not reported as failed", s.State)
+	}
+
+	status = `<response></response>`
+	if s, err = h.GetConnectionStatus(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.State.Failed() || s.WanIP != nil {
+		t.Errorf("empty status decoded to state %v, failed %v, WanIP %v", s.State, s.State.Failed(), s.WanIP)
+	}
+}
@@ -0,0 +1,361 @@
+package huawei
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval WatchSMS uses when none is
+// given.
+const DefaultWatchInterval = 5 * time.Second
+
+type notificationsResponse struct {
+	XMLName        xml.Name `xml:"response"`
+	UnreadMessage  int      `xml:"UnreadMessage"`
+	SmsStorageFull int      `xml:"SmsStorageFull"`
+}
+
+// WatchOptions configures WatchSMS.
+type WatchOptions struct {
+	// Box is the box to watch, Inbox if zero.
+	Box Box
+	// Interval is the delay between two polls, DefaultWatchInterval if zero.
+	Interval time.Duration
+	// IncludeExisting delivers the messages already in the box when the
+	// watch starts. By default only messages arriving later are delivered.
+	IncludeExisting bool
+	// MarkRead marks messages as read once they are delivered.
+	MarkRead bool
+	// Delete deletes messages once they are delivered.
+	Delete bool
+}
+
+// SMSEvent is delivered by WatchSMS for each new message, or for an error
+// that occurred while watching. The watch carries on after errors.
+type SMSEvent struct {
+	SMS SMS
+	Err error
+}
+
+// smsKey identifies a message across polls. Indexes may be reused once a
+// message is deleted or the device restarts, so the date is part of it.
+type smsKey struct {
+	index int
+	date  string
+}
+
+// smsWatcher holds the state of a WatchSMS loop.
+type smsWatcher struct {
+	h      *Huawei
+	opts   WatchOptions
+	events chan SMSEvent
+
+	// seen holds the messages of the last listing, all of which were
+	// delivered or present at the start.
+	seen map[smsKey]bool
+	// primed is set once the messages present at the start are known.
+	primed bool
+	// since is the date from which messages present at the start are
+	// delivered as new, none if zero.
+	since time.Time
+	// signature is the state of the box at the last listing; the box is
+	// only listed again once it changes.
+	signature *boxSignature
+}
+
+// boxSignature summarizes the counters of the device that change whenever
+// a message arrives.
+type boxSignature struct {
+	total, unread, notified int
+}
+
+// WatchSMS watches a box for new messages and delivers them on the returned
+// channel, oldest first, until ctx is done. The channel is closed then.
+//
+// Every poll asks /api/monitoring/check-notifications and /api/sms/sms-count
+// whether anything changed, and lists the box only if so. Messages are
+// told apart by index and date, so none is delivered twice. Failed polls,
+// e.g. while the device restarts, are delivered as events carrying the
+// error and retried at the next interval; with KeepCredentials the client
+// logs in again on its own once the device is back.
+//
+// Parameters:
+//   - ctx: The context ending the watch.
+//   - opts: The box to watch, polling interval and handling of delivered messages.
+//
+// Returns:
+//   - <-chan SMSEvent: The new messages and errors.
+func (h *Huawei) WatchSMS(ctx context.Context, opts WatchOptions) <-chan SMSEvent {
+	return h.watchSMS(ctx, opts, time.Time{})
+}
+
+// watchSMS is WatchSMS, also delivering the messages present at the start
+// that are dated since then.
+func (h *Huawei) watchSMS(ctx context.Context, opts WatchOptions, since time.Time) <-chan SMSEvent {
+	if opts.Box == 0 {
+		opts.Box = Inbox
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+
+	w := &smsWatcher{
+		h:      h,
+		opts:   opts,
+		events: make(chan SMSEvent),
+		seen:   make(map[smsKey]bool),
+		primed: opts.IncludeExisting,
+		since:  since,
+	}
+	go w.run(ctx)
+	return w.events
+}
+
+// run polls the device until ctx is done.
+func (w *smsWatcher) run(ctx context.Context) {
+	defer close(w.events)
+	for {
+		if err := w.poll(ctx); err != nil && ctx.Err() == nil {
+			w.h.log().Warn("watching SMS failed", "box", w.opts.Box, "error", err)
+			if !w.emit(ctx, SMSEvent{Err: err}) {
+				return
+			}
+		}
+		if sleep(ctx, w.opts.Interval) != nil {
+			return
+		}
+	}
+}
+
+// poll delivers the messages that arrived since the last poll.
+func (w *smsWatcher) poll(ctx context.Context) error {
+	sig, err := w.box(ctx)
+	if err != nil {
+		return err
+	}
+	if w.signature != nil && *w.signature == *sig {
+		return nil
+	}
+
+	messages, err := w.unseen(ctx)
+	if err != nil {
+		return err
+	}
+	w.signature = sig
+
+	if !w.primed {
+		w.primed = true
+		messages = slices.DeleteFunc(messages, func(msg SMS) bool {
+			return w.since.IsZero() || msg.Date.Before(w.since)
+		})
+	}
+	for _, msg := range messages {
+		if !w.emit(ctx, SMSEvent{SMS: msg}) {
+			return ctx.Err()
+		}
+	}
+	if err := w.handled(ctx, messages); err != nil {
+		// Some messages may have been handled, list the box again next time.
+		w.signature = nil
+		return err
+	}
+	return nil
+}
+
+// box returns the current signature of the watched box.
+func (w *smsWatcher) box(ctx context.Context) (*boxSignature, error) {
+	sig := &boxSignature{}
+
+	// Not every firmware provides notifications; the counters suffice.
+	notifications, err := do[notificationsResponse](ctx, w.h, "GET", "/api/monitoring/check-notifications", nil)
+	switch {
+	case err == nil:
+		sig.notified = notifications.UnreadMessage
+	case !errors.Is(err, ErrNotSupported):
+		return nil, err
+	}
+
+	count, err := w.h.GetSmsCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch w.opts.Box {
+	case Inbox:
+		sig.total, sig.unread = count.LocalInbox, count.LocalUnread
+	case Outbox:
+		sig.total = count.LocalOutbox
+	case Draft:
+		sig.total = count.LocalDraft
+	case Trash:
+		sig.total = count.LocalDeleted
+	case SimInbox:
+		sig.total, sig.unread = count.SimInbox, count.SimUnread
+	case SimOutbox:
+		sig.total = count.SimOutbox
+	case SimDraft:
+		sig.total = count.SimDraft
+	}
+	return sig, nil
+}
+
+// unseen lists the whole box and returns the messages not seen before,
+// oldest first. Messages are not assumed to arrive in date order, since the
+// device clock may jump back after a restart and several messages may
+// share a timestamp. Only the messages present in this listing are
+// remembered as seen, so the set does not grow beyond the box.
+func (w *smsWatcher) unseen(ctx context.Context) ([]SMS, error) {
+	var messages []SMS
+	present := make(map[smsKey]bool)
+	for msg, err := range w.h.AllSMS(ctx, ListOptions{Box: w.opts.Box, PageSize: MaxPageSize}) {
+		if err != nil {
+			return nil, err
+		}
+		key := keyOf(msg)
+		if !w.seen[key] && !present[key] {
+			messages = append(messages, msg)
+		}
+		present[key] = true
+	}
+	w.seen = present
+
+	slices.SortStableFunc(messages, func(a, b SMS) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return messages, nil
+}
+
+// handled marks as read or deletes the delivered messages, as configured.
+// The signature is updated to the counters this leaves behind, so that
+// handling messages does not cause the box to be listed again.
+func (w *smsWatcher) handled(ctx context.Context, messages []SMS) error {
+	if len(messages) == 0 || !w.opts.Delete && !w.opts.MarkRead {
+		return nil
+	}
+	indexes := Indexes(messages)
+	if w.opts.Delete {
+		if err := w.h.DeleteSMS(ctx, indexes...); err != nil {
+			return err
+		}
+		w.signature.total -= len(messages)
+	} else if err := w.h.MarkRead(ctx, indexes...); err != nil {
+		return err
+	}
+
+	unread := 0
+	for _, msg := range messages {
+		if msg.Status == SMSUnread {
+			unread++
+		}
+	}
+	w.signature.unread = max(w.signature.unread-unread, 0)
+	w.signature.notified = max(w.signature.notified-unread, 0)
+	return nil
+}
+
+// emit delivers ev, reporting false if ctx ended the watch first.
+func (w *smsWatcher) emit(ctx context.Context, ev SMSEvent) bool {
+	select {
+	case w.events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func keyOf(msg SMS) smsKey {
+	return smsKey{index: msg.Index, date: msg.Raw.Date}
+}
+
+// ClockSkew is how far the device clock may lag behind the host's for a
+// message to still match Match.After.
+const ClockSkew = 2 * time.Minute
+
+// Match selects the message WaitForSMS waits for. Zero fields match every
+// message.
+type Match struct {
+	// From is the sender, either a phone number, compared leniently so
+	// that national and international formats of a number match, or an
+	// alphanumeric sender name, compared ignoring case.
+	From string
+	// ContentRegexp must match the text of the message.
+	ContentRegexp *regexp.Regexp
+	// After is the earliest date of the message, the time WaitForSMS is
+	// called if zero. The device dates messages to the second by a clock
+	// that may lag behind, so messages arriving later and dated up to
+	// ClockSkew before After still match.
+	After time.Time
+	// Interval is the polling interval, DefaultWatchInterval if zero.
+	Interval time.Duration
+}
+
+// matches reports whether msg matches m and returns the submatches of
+// ContentRegexp, if set.
+func (m *Match) matches(msg SMS) ([]string, bool) {
+	if m.From != "" && !strings.EqualFold(msg.Phone, m.From) &&
+		(!isPhoneNumber(m.From) || !isPhoneNumber(msg.Phone) || !containsPhone([]string{msg.Phone}, m.From)) {
+		return nil, false
+	}
+	if !m.After.IsZero() && msg.Date.Before(m.After.Truncate(time.Second).Add(-ClockSkew)) {
+		return nil, false
+	}
+	if m.ContentRegexp == nil {
+		return nil, true
+	}
+	captures := m.ContentRegexp.FindStringSubmatch(msg.Content)
+	return captures, captures != nil
+}
+
+// WaitForSMS blocks until a message matching m arrives in the inbox, e.g. a
+// one-time password. Messages already in the inbox when it is called are
+// ignored unless dated at or after m.After, so that one arriving while the
+// inbox is first listed is not missed.
+//
+// Parameters:
+//   - ctx: The context bounding the wait.
+//   - m: The sender, text and earliest date of the message to wait for.
+//
+// Returns:
+//   - SMS: The first matching message.
+//   - []string: The match of ContentRegexp followed by its submatches, nil
+//     if m has no ContentRegexp.
+//   - error: An error if ctx is done before a matching message arrives,
+//     joined with the last error polling the device, if any.
+func (h *Huawei) WaitForSMS(ctx context.Context, m Match) (SMS, []string, error) {
+	if m.After.IsZero() {
+		m.After = time.Now()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lastErr error
+	for ev := range h.watchSMS(ctx, WatchOptions{Interval: m.Interval}, m.After.Truncate(time.Second)) {
+		if ev.Err != nil {
+			lastErr = ev.Err
+			continue
+		}
+		if captures, ok := m.matches(ev.SMS); ok {
+			return ev.SMS, captures, nil
+		}
+	}
+	return SMS{}, nil, errors.Join(ctx.Err(), lastErr)
+}
+
+// isPhoneNumber reports whether s is a phone number rather than an
+// alphanumeric sender name: digits, optionally with a leading '+' and
+// spaces, dashes or parentheses as separators.
+func isPhoneNumber(s string) bool {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "+")
+	if digits(s) == "" {
+		return false
+	}
+	return strings.Trim(s, "0123456789 -()") == ""
+}
@@ -0,0 +1,192 @@
+package huawei
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unreadSMS returns a new message from phone for a fakeDevice.
+func unreadSMS(index int, phone, text, date string) fakeSMS {
+	return fakeSMS{Index: index, Phone: phone, Text: text, Date: date, Unread: true}
+}
+
+func TestWatchDeliversOutOfOrderMessages(t *testing.T) {
+	d := newFakeDevice(t)
+	d.setMessages(unreadSMS(40001, "100", "old", "2024-05-01 10:00:00"))
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &smsWatcher{h: h, opts: WatchOptions{Box: Inbox}, events: make(chan SMSEvent, 10), seen: make(map[smsKey]bool)}
+	ctx := context.Background()
+	if err := w.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clock jumped back after a restart, and two messages share a second.
+	d.setMessages(
+		unreadSMS(40001, "100", "old", "2024-05-01 10:00:00"),
+		unreadSMS(40003, "100", "second", "2024-01-01 00:00:05"),
+		unreadSMS(40002, "100", "first", "2024-01-01 00:00:05"),
+	)
+	if err := w.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for len(w.events) > 0 {
+		got = append(got, (<-w.events).SMS.Content)
+	}
+	if strings.Join(got, ",") != "first,second" {
+		t.Errorf("delivered %q, want [first second]", got)
+	}
+
+	d.setMessages(unreadSMS(40003, "100", "second", "2024-01-01 00:00:05"))
+	if err := w.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.events) != 0 {
+		t.Errorf("delivered %d messages after a deletion", len(w.events))
+	}
+	if len(w.seen) != 1 {
+		t.Errorf("watcher remembers %d messages, want 1", len(w.seen))
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 700_000_000, time.Local)
+	otp := regexp.MustCompile(`code (\d+)`)
+	tests := []struct {
+		name  string
+		m     Match
+		phone string
+		date  time.Time
+		want  bool
+	}{
+		{"same number", Match{From: "+989121234567"}, "+989121234567", now, true},
+		{"national format", Match{From: "09121234567"}, "+989121234567", now, true},
+		{"other number", Match{From: "09121234567"}, "+989127654321", now, false},
+		{"sender name", Match{From: "bank1"}, "Bank1", now, true},
+		{"names sharing digits", Match{From: "Bank1"}, "Shop1", now, false},
+		{"number against name", Match{From: "1"}, "Shop1", now, false},
+		{"same second", Match{After: now}, "Bank1", now.Truncate(time.Second), true},
+		{"device clock behind", Match{After: now}, "Bank1", now.Add(-time.Minute), true},
+		{"before the call", Match{After: now}, "Bank1", now.Add(-time.Hour), false},
+		{"content", Match{ContentRegexp: otp}, "Bank1", now, true},
+	}
+	for _, tt := range tests {
+		msg := SMS{Phone: tt.phone, Date: tt.date, Content: "your code 4242"}
+		if _, got := tt.m.matches(msg); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWaitForSMS(t *testing.T) {
+	d := newFakeDevice(t)
+	d.setMessages(unreadSMS(40001, "Bank", "your code 1111", "2024-05-01 10:00:00"))
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		// Dated in the same second as the call, as the device reports it.
+		d.setMessages(
+			unreadSMS(40002, "Bank", "your code 4242", start.Format(smsDateLayout)),
+			unreadSMS(40001, "Bank", "your code 1111", "2024-05-01 10:00:00"),
+		)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg, captures, err := h.WaitForSMS(ctx, Match{
+		From:          "Bank",
+		ContentRegexp: regexp.MustCompile(`code (\d+)`),
+		After:         start,
+		Interval:      10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Index != 40002 || len(captures) != 2 || captures[1] != "4242" {
+		t.Errorf("WaitForSMS = %d, %q", msg.Index, captures)
+	}
+}
+
+func TestWatchHandlesDeliveredMessages(t *testing.T) {
+	for _, opts := range []WatchOptions{{Box: Inbox, MarkRead: true}, {Box: Inbox, Delete: true}} {
+		d := newFakeDevice(t)
+		d.setMessages(unreadSMS(40001, "100", "old", "2024-05-01 10:00:00"))
+		h, err := NewHuawei(d.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := &smsWatcher{h: h, opts: opts, events: make(chan SMSEvent, 10), seen: make(map[smsKey]bool)}
+		ctx := context.Background()
+		if err := w.poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+		d.setMessages(
+			unreadSMS(40002, "100", "new", "2024-05-01 10:01:00"),
+			unreadSMS(40001, "100", "old", "2024-05-01 10:00:00"),
+		)
+		if err := w.poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(w.events) != 1 {
+			t.Fatalf("%+v: delivered %d messages, want 1", opts, len(w.events))
+		}
+
+		switch unread := d.unread(); {
+		case opts.MarkRead && !slices.Equal(unread, []int{40001}):
+			t.Errorf("%+v: unread messages %v, want [40001]", opts, unread)
+		case opts.Delete && d.count("/api/sms/delete-sms") != 1:
+			t.Errorf("%+v: delivered message not deleted", opts)
+		}
+
+		listings := d.count("/api/sms/sms-list")
+		if err := w.poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if n := d.count("/api/sms/sms-list") - listings; n != 0 {
+			t.Errorf("%+v: handling the message caused %d listings", opts, n)
+		}
+	}
+}
+
+func TestWaitForSMSDuringFirstListing(t *testing.T) {
+	d := newFakeDevice(t)
+	d.setMessages(unreadSMS(40001, "Bank", "your code 1111", "2024-05-01 10:00:00"))
+	// The code arrives while the inbox is listed for the first time.
+	d.handle("/api/monitoring/check-notifications", func(w http.ResponseWriter, r *http.Request) {
+		d.setMessages(
+			unreadSMS(40002, "Bank", "your code 4242", time.Now().Format(smsDateLayout)),
+			unreadSMS(40001, "Bank", "your code 1111", "2024-05-01 10:00:00"),
+		)
+		fmt.Fprint(w, `<error><code>100002</code><message></message></error>`)
+	})
+	h, err := NewHuawei(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, _, err := h.WaitForSMS(ctx, Match{From: "Bank", Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Index != 40002 {
+		t.Errorf("WaitForSMS = %d, want 40002", msg.Index)
+	}
+}